@@ -9,9 +9,11 @@ require (
 )
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/schema v1.4.1
 	github.com/itzg/go-flagsfiller v1.9.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
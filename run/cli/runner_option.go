@@ -7,14 +7,22 @@ import (
 	"github.com/nextmv-io/sdk/run/encode"
 )
 
-// Decode sets the decoder of a CLIRunner.
+// Decode sets the decoder of a CLIRunner. The default JSON schema validation
+// of the input is turned off for decoders of other formats, e.g. decode.CSV.
+// Pass Validate to validate such inputs.
 func Decode[Input, Option, Solution any, Decoder decode.Decoder](
 	d Decoder,
 ) func(
 	run.Runner[run.CLIRunnerConfig, Input, Option, Solution],
 ) {
-	return run.InputDecode[run.CLIRunnerConfig, Input, Option, Solution](
-		run.GenericDecoder[Input](d),
+	decoder := run.GenericDecoder[Input](d)
+	if _, ok := any(d).(decode.JSONDecoder); ok {
+		return run.InputDecode[run.CLIRunnerConfig, Input, Option, Solution](
+			decoder,
+		)
+	}
+	return run.InputDecodeFormat[run.CLIRunnerConfig, Input, Option, Solution](
+		decoder,
 	)
 }
 
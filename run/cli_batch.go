@@ -0,0 +1,316 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nextmv-io/sdk/run/statistics"
+)
+
+// Statuses of the inputs of a batch run.
+const (
+	BatchSucceeded = "succeeded"
+	BatchFailed    = "failed"
+	// BatchSkipped is the status of inputs which were not solved, because
+	// the batch run was stopped.
+	BatchSkipped = "skipped"
+)
+
+// BatchSummary is written to the output directory at the end of a batch run.
+type BatchSummary struct {
+	// Inputs is the number of input files.
+	Inputs int `json:"inputs"`
+	// Failed is the number of inputs which failed or were skipped.
+	Failed int `json:"failed"`
+	// Duration of the batch run in seconds.
+	Duration float64 `json:"duration"`
+	// Results of the inputs in the order of their paths.
+	Results []BatchResult `json:"results"`
+}
+
+// BatchResult is the result of an input file of a batch run.
+type BatchResult struct {
+	// Input is the path of the input file relative to the input directory.
+	Input string `json:"input"`
+	// Output is the path of the output file relative to the output
+	// directory. It is empty if no output was written, because the run
+	// failed or was skipped.
+	Output string `json:"output,omitempty"`
+	Status string `json:"status"`
+	// Duration of the run in seconds.
+	Duration float64 `json:"duration"`
+	// Value is the value of the last solution, see Valuer.
+	Value *statistics.Float64 `json:"value,omitempty"`
+	Error string              `json:"error,omitempty"`
+}
+
+// BatchError is returned by the CLI runner if inputs of a batch run failed.
+type BatchError struct {
+	Failed int
+	Inputs int
+}
+
+func (e BatchError) Error() string {
+	return fmt.Sprintf("%d of %d inputs failed", e.Failed, e.Inputs)
+}
+
+// batchInput is an input file and the output file it is solved into.
+type batchInput struct {
+	// name is the path of the input relative to the input directory.
+	name   string
+	input  string
+	output string
+}
+
+// runBatch solves every input file with the configured number of workers and
+// writes the summary. The outputs mirror the names of the inputs, so that the
+// extension of an input also selects the format of its output.
+func (r *cliRunner[Input, Option, Solution]) runBatch(
+	ctx context.Context, config CLIRunnerConfig,
+) error {
+	start := time.Now()
+	if err := checkBatch(config); err != nil {
+		return err
+	}
+	inputs, err := r.batchInputs(config)
+	if err != nil {
+		return err
+	}
+
+	results := make([]BatchResult, len(inputs))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(config.Runner.Batch.Workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = r.solve(ctx, config, inputs[i])
+			}
+		}()
+	}
+	for i := range inputs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	summary := BatchSummary{
+		Inputs:   len(inputs),
+		Duration: time.Since(start).Seconds(),
+		Results:  results,
+	}
+	for _, result := range results {
+		if result.Status != BatchSucceeded {
+			summary.Failed++
+		}
+	}
+	if err := writeSummary(config, summary); err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return BatchError{Failed: summary.Failed, Inputs: summary.Inputs}
+	}
+	return nil
+}
+
+// checkBatch returns an error for flags which cannot be used in batch mode.
+func checkBatch(config CLIRunnerConfig) error {
+	switch {
+	case config.Runner.Input.Path == "":
+		return errors.New("batch mode needs an input directory or glob")
+	case config.Runner.Output.Path != "":
+		return errors.New("output path cannot be used with an output directory")
+	case config.Runner.Profile.CPU != "" || config.Runner.Profile.Memory != "":
+		return errors.New("profiles cannot be written in batch mode")
+	}
+	return nil
+}
+
+// batchInputs lists the files in the input directory and its subdirectories
+// or the files matching the input glob, in lexical order. The output
+// directory is left out, in case it is inside the input directory.
+func (r *cliRunner[Input, Option, Solution]) batchInputs(
+	config CLIRunnerConfig,
+) ([]batchInput, error) {
+	pattern := config.Runner.Input.Path
+	outputDir, err := filepath.Abs(config.Runner.Output.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var root string
+	var paths []string
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		root = pattern
+		err := filepath.WalkDir(root, func(
+			path string, entry fs.DirEntry, err error,
+		) error {
+			switch {
+			case err != nil:
+				return err
+			case entry.IsDir() && isDir(path, outputDir):
+				return filepath.SkipDir
+			case !entry.IsDir():
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		root = globRoot(pattern)
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				paths = append(paths, match)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no input files found in %s", pattern)
+	}
+
+	inputs := make([]batchInput, len(paths))
+	for i, path := range paths {
+		name, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, err
+		}
+		inputs[i] = batchInput{
+			name:  name,
+			input: path,
+			output: filepath.Join(
+				config.Runner.Output.Dir, r.codecs.outputName(name),
+			),
+		}
+	}
+	return inputs, nil
+}
+
+// isDir reports whether path is the directory with the absolute path dir.
+func isDir(path, dir string) bool {
+	abs, err := filepath.Abs(path)
+	return err == nil && abs == dir
+}
+
+// globRoot returns the directory of the glob pattern up to the first element
+// with a meta character.
+func globRoot(pattern string) string {
+	root := filepath.Dir(pattern)
+	for strings.ContainsAny(root, `*?[\`) {
+		root = filepath.Dir(root)
+	}
+	return root
+}
+
+// outputName returns the name of the output of the input with the given name.
+// A trailing .gz is removed and inputs without an encoder for their
+// extension, such as zip archives, get a JSON output.
+func (c codecs) outputName(name string) string {
+	name = strings.TrimSuffix(name, ".gz")
+	if _, ok := c.encoders[pathType(name)]; !ok {
+		name = strings.TrimSuffix(name, filepath.Ext(name)) + ".json"
+	}
+	return name
+}
+
+// solve runs the runner on an input of a batch run.
+func (r *cliRunner[Input, Option, Solution]) solve(
+	ctx context.Context, config CLIRunnerConfig, input batchInput,
+) BatchResult {
+	result := BatchResult{
+		Input:  filepath.ToSlash(input.name),
+		Status: BatchSkipped,
+	}
+	if ctx.Err() != nil {
+		return result
+	}
+
+	start := time.Now()
+	var mutex sync.Mutex
+	var last any
+	ctx = withSolutionObserver(ctx, func(solution any) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		last = solution
+	})
+	ctx = context.WithValue(ctx, negotiated, r.codecs.negotiatePaths(
+		input.input, input.output,
+	))
+	// the IOProducer of the runner is replaced, to read and write the files
+	// of the input
+	config.Runner.Input.Path = input.input
+	config.Runner.Output.Path = input.output
+	var writer io.Closer
+	err := os.MkdirAll(filepath.Dir(input.output), 0o750)
+	if err == nil {
+		err = r.RunWith(ctx, func(
+			ctx context.Context, _ CLIRunnerConfig,
+		) (IOData, error) {
+			data, err := CliIOProducer(ctx, config)
+			if err == nil {
+				writer, _ = data.Writer().(io.Closer)
+			}
+			return data, err
+		})
+	}
+	result.Duration = time.Since(start).Seconds()
+
+	if err != nil {
+		// the encoder closes the output, unless the run failed before
+		if writer != nil {
+			_ = writer.Close()
+		}
+		_ = os.Remove(input.output)
+		result.Status = BatchFailed
+		result.Error = err.Error()
+		return result
+	}
+	result.Output = filepath.ToSlash(r.codecs.outputName(input.name))
+	result.Status = BatchSucceeded
+	mutex.Lock()
+	defer mutex.Unlock()
+	result.Value = resultValue(last)
+	return result
+}
+
+// resultValue returns the value of the solution, see Valuer.
+func resultValue(solution any) *statistics.Float64 {
+	value, ok := solutionValue(solution)
+	if !ok {
+		return nil
+	}
+	v := statistics.Float64(value)
+	return &v
+}
+
+// writeSummary writes the summary of a batch run to the output directory.
+func writeSummary(config CLIRunnerConfig, summary BatchSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(config.Runner.Output.Dir, config.Runner.Batch.Summary)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,47 @@
+package run
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// extensionTypes maps the extensions of input and output paths to the content
+// types of their codecs.
+var extensionTypes = map[string]string{
+	".json": "application/json",
+	".yaml": "application/yaml",
+	".yml":  "application/yaml",
+	".toml": "application/toml",
+	".xml":  "application/xml",
+	".gob":  "application/gob",
+	".csv":  "text/csv",
+	".zip":  "application/zip",
+}
+
+// negotiatePaths selects the decoder and the encoder by the extensions of the
+// input and output paths, ignoring a trailing .gz. Empty paths, i.e. stdin and
+// stdout, and paths with an unknown extension are JSON.
+func (c codecs) negotiatePaths(input, output string) negotiation {
+	n := negotiation{
+		contentType: pathType(input),
+		encoderType: pathType(output),
+	}
+	n.decoder = c.decoders[n.contentType]
+	encoder, ok := c.encoders[n.encoderType]
+	if !ok {
+		// e.g. a zip archive, which is only read
+		n.encoderType = "application/json"
+		encoder = c.encoders[n.encoderType]
+	}
+	n.encoder = encoder
+	return n
+}
+
+// pathType returns the content type of a path by its extension.
+func pathType(path string) string {
+	path = strings.TrimSuffix(strings.ToLower(path), ".gz")
+	if contentType, ok := extensionTypes[filepath.Ext(path)]; ok {
+		return contentType
+	}
+	return "application/json"
+}
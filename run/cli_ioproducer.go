@@ -8,15 +8,24 @@ import (
 
 // CliIOProducer is the IOProducer for the CliRunner. The input and output paths
 // are used to configure the input and output readers and writers. If the paths
-// are empty, os.Stdin and os.Stdout are used.
+// are empty, os.Stdin and os.Stdout are used. An input directory is passed on
+// as an fs.FS, for decoders which read several files, see decode.FSDecoder.
 func CliIOProducer(_ context.Context, cfg CLIRunnerConfig) (IOData, error) {
-	reader := os.Stdin
+	var reader any = os.Stdin
 	if cfg.Runner.Input.Path != "" {
-		r, err := os.Open(cfg.Runner.Input.Path)
+		info, err := os.Stat(cfg.Runner.Input.Path)
 		if err != nil {
 			return ioData{}, err
 		}
-		reader = r
+		if info.IsDir() {
+			reader = os.DirFS(cfg.Runner.Input.Path)
+		} else {
+			r, err := os.Open(cfg.Runner.Input.Path)
+			if err != nil {
+				return ioData{}, err
+			}
+			reader = r
+		}
 	}
 	var writer io.Writer = os.Stdout
 	if cfg.Runner.Output.Path != "" {
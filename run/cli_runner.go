@@ -1,31 +1,151 @@
 package run
 
 import (
-	"github.com/nextmv-io/sdk/run/decode"
-	"github.com/nextmv-io/sdk/run/encode"
-	"github.com/nextmv-io/sdk/run/validate"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 // NewCLIRunner is the default CLI runner. It reads the input from stdin or a
-// file, writes output to stdout or a file, decodes the input using the JSON
-// decoder, accepts options from the command line, and encodes the solution
-// using the JSON encoder.
+// file, writes output to stdout or a file, decodes the input according to the
+// extension of the input path, accepts options from the command line, and
+// encodes the solution according to the extension of the output path. JSON,
+// YAML, TOML, XML, Gob and CSV are supported, stdin and stdout are JSON. JSON,
+// YAML and TOML inputs are validated against the JSON schema of the input.
+//
+// If an output directory is configured, the runner solves every file of the
+// input directory, or every file matching the input glob, in batch mode. The
+// outputs are written to files with the same names in the output directory,
+// along with a BatchSummary of all inputs. The IOProducer of the runner is not
+// used in batch mode. On SIGINT or SIGTERM the algorithm context is
+// cancelled, the solutions received so far are encoded and Run returns a
+// SignalError, joined with the error of the run if there is one. A second
+// signal terminates the process immediately.
 func NewCLIRunner[Input, Option, Solution any](
 	algorithm Algorithm[Input, Option, Solution],
 	options ...RunnerOption[CLIRunnerConfig, Input, Option, Solution],
 ) Runner[CLIRunnerConfig, Input, Option, Solution] {
-	runner := GenericRunner(
+	runner, err := NewCLIRunnerFromFlags(
+		flag.CommandLine, os.Args[1:], algorithm, options...,
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return runner
+}
+
+// NewCLIRunnerFromFlags creates the default CLI runner like NewCLIRunner, but
+// parses its configuration from args with fs and returns an error instead of
+// exiting. See NewGenericRunner.
+func NewCLIRunnerFromFlags[Input, Option, Solution any](
+	fs *flag.FlagSet,
+	args []string,
+	algorithm Algorithm[Input, Option, Solution],
+	options ...RunnerOption[CLIRunnerConfig, Input, Option, Solution],
+) (Runner[CLIRunnerConfig, Input, Option, Solution], error) {
+	generic := newGenericRunner(
 		CliIOProducer,
-		GenericDecoder[Input](decode.JSON()),
-		validate.JSON[Input](nil),
+		negotiatingDecoder[Input],
+		jsonValidator[Input](),
 		NoopOptionsDecoder[Option],
 		algorithm,
-		GenericEncoder[Solution, Option](encode.JSON()),
+		&negotiatingEncoder[Solution, Option]{},
 	)
+	generic.defaultValidator = true
+	runner := &cliRunner[Input, Option, Solution]{
+		Runner: generic,
+		codecs: newCodecs(),
+	}
 
 	for _, option := range options {
 		option(runner)
 	}
 
-	return runner
+	if err := generic.configure(fs, args); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+type cliRunner[Input, Option, Solution any] struct {
+	Runner[CLIRunnerConfig, Input, Option, Solution]
+	codecs codecs
+}
+
+func (r *cliRunner[Input, Option, Solution]) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	var received os.Signal
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case received = <-signals:
+			// restore the default behavior, so that a second signal
+			// terminates the process right away
+			signal.Stop(signals)
+			cancel(TerminationSignal)
+		case <-ctx.Done():
+		}
+	}()
+
+	err := r.run(ctx)
+	cancel(nil)
+	<-done
+	if received != nil {
+		// the signal comes first, so that ExitCode finds it before any exit
+		// code of the error of the run
+		err = errors.Join(SignalError{Signal: received}, err)
+	}
+	return err
+}
+
+func (r *cliRunner[Input, Option, Solution]) setFormatDecoder(
+	decoder Decoder[Input],
+) {
+	if f, ok := r.Runner.(formatDecoder[Input]); ok {
+		f.setFormatDecoder(decoder)
+		return
+	}
+	r.SetInputDecoder(decoder)
+}
+
+// run solves the input or, if an output directory is configured, all inputs
+// of a batch run.
+func (r *cliRunner[Input, Option, Solution]) run(ctx context.Context) error {
+	config := r.RunnerConfig()
+	if config.Runner.Output.Dir != "" {
+		return r.runBatch(ctx, config)
+	}
+	ctx = context.WithValue(ctx, negotiated, r.codecs.negotiatePaths(
+		config.Runner.Input.Path, config.Runner.Output.Path,
+	))
+	return r.Runner.Run(ctx)
+}
+
+// SignalError is returned by the CLI runner if it was stopped by a signal.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e SignalError) Error() string {
+	return "run stopped by signal: " + e.Signal.String()
+}
+
+// ExitCode returns 128 plus the signal number, as is the convention for
+// processes terminated by a signal.
+func (e SignalError) ExitCode() int {
+	if s, ok := e.Signal.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
 }
@@ -1,6 +1,9 @@
 package run
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // CPUProfiler is the interface a runner configuration can implement to return
 // the CPU profile path.
@@ -26,11 +29,36 @@ type SolutionLimiter interface {
 	Solutions() (Solutions, error)
 }
 
+// TimingsReporter is the interface a runner configuration can implement to
+// add the Timings of a run to the statistics of schema.Output solutions.
+type TimingsReporter interface {
+	ReportTimings() bool
+}
+
+// DurationLimiter is the interface a runner configuration can implement to
+// return the maximum duration of a run. A zero duration means no limit.
+type DurationLimiter interface {
+	DurationLimit() time.Duration
+}
+
+// GraceLimiter is the interface a runner configuration can implement to return
+// how long the runner waits for a stopped algorithm to return. A zero duration
+// means the default of one second.
+type GraceLimiter interface {
+	GracePeriod() time.Duration
+}
+
+// CriteriaLimiter is the interface a runner configuration can implement to
+// return the Criteria under which a run is stopped early.
+type CriteriaLimiter interface {
+	Criteria() (Criteria, error)
+}
+
 // CLIRunnerConfig is the configuration of the  CliRunner.
 type CLIRunnerConfig struct {
 	Runner struct {
 		Input struct {
-			Path string `usage:"The input file path"`
+			Path string `usage:"The input file or directory path, or a glob of input files in batch mode"`
 		}
 		Profile struct {
 			CPU    string `usage:"The CPU profile file path"`
@@ -38,7 +66,25 @@ type CLIRunnerConfig struct {
 		}
 		Output struct {
 			Path      string `usage:"The output file path"`
+			Dir       string `usage:"The output directory, which turns on batch mode to solve every input file"`
 			Solutions string `default:"last" usage:"{all, last}"`
+			Timings   bool   `usage:"Add the timings of the run to the statistics of schema.Output solutions"`
+		}
+		Batch struct {
+			Workers int    `default:"1" usage:"The number of input files solved in parallel in batch mode"`
+			Summary string `default:"summary.json" usage:"The file in the output directory with the result of every input"`
+		}
+		Limits struct {
+			Duration    time.Duration `usage:"The maximum duration of the run"`
+			Grace       time.Duration `default:"1s" usage:"How long a stopped algorithm may take to return before it is abandoned"`
+			Solutions   int           `usage:"The maximum number of solutions, the run stops once the algorithm sent them"`
+			Target      string        `usage:"The solution value at which the run stops"`
+			Improvement time.Duration `usage:"How long the solution value may not improve before the run stops"`
+			Objective   string        `default:"minimize" usage:"Whether a smaller or larger solution value is better {minimize, maximize}"`
+		}
+		Replay struct {
+			Diff   bool     `usage:"Run the algorithm on inputs with a recorded output and compare the last solution with it"`
+			Ignore []string `default:".statistics.run.duration,.statistics.run.custom.timings,.statistics.result.duration,.statistics.series_data" usage:"The keys not compared with the recorded output"`
 		}
 	}
 }
@@ -58,6 +104,41 @@ func (c CLIRunnerConfig) MemoryProfilePath() string {
 	return c.Runner.Profile.Memory
 }
 
+// DurationLimit returns the maximum duration of the run.
+func (c CLIRunnerConfig) DurationLimit() time.Duration {
+	return c.Runner.Limits.Duration
+}
+
+// GracePeriod returns how long a stopped algorithm may take to return.
+func (c CLIRunnerConfig) GracePeriod() time.Duration {
+	return c.Runner.Limits.Grace
+}
+
+// Criteria returns the criteria under which the run is stopped early.
+func (c CLIRunnerConfig) Criteria() (Criteria, error) {
+	limits := c.Runner.Limits
+	return parseCriteria(
+		limits.Solutions, limits.Target, limits.Objective, limits.Improvement,
+	)
+}
+
+// ReplayDiff reports whether the algorithm is run on inputs with a recorded
+// output to compare its last solution with it.
+func (c CLIRunnerConfig) ReplayDiff() bool {
+	return c.Runner.Replay.Diff
+}
+
+// ReplayIgnore returns the keys not compared with the recorded output.
+func (c CLIRunnerConfig) ReplayIgnore() []string {
+	return c.Runner.Replay.Ignore
+}
+
+// ReportTimings reports whether the timings of the run are added to the
+// statistics of schema.Output solutions.
+func (c CLIRunnerConfig) ReportTimings() bool {
+	return c.Runner.Output.Timings
+}
+
 // Solutions returns the configured solutions.
 func (c CLIRunnerConfig) Solutions() (Solutions, error) {
 	return ParseSolutions(c.Runner.Output.Solutions)
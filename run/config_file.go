@@ -0,0 +1,111 @@
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/nextmv-io/sdk/run/decode"
+)
+
+// configFlags are the flags of FlagParser that are not part of the runner
+// configuration.
+type configFlags struct {
+	Runner struct {
+		Config string `usage:"The JSON, YAML or TOML file with option and runner configuration values"`
+		Print  struct {
+			Config bool `usage:"Print the resolved configuration as JSON and exit"`
+		}
+	}
+}
+
+// loadConfigFile decodes the file at path into option and runnerConfig. The
+// file is a single document which holds the options at the top level and the
+// runner configuration under the runner key, mirroring the flag names, e.g.:
+//
+//	{"duration": 1000000000, "runner": {"output": {"path": "out.json"}}}
+//
+// Options are decoded using their json struct tags. Only the fields present in
+// the file are changed. YAML files are recognized by their .yaml or .yml
+// extension and TOML files by their .toml extension.
+func loadConfigFile(path string, option, runnerConfig any) error {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	var decoder decode.Decoder = decode.JSON()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder = decode.YAML()
+	case ".toml":
+		decoder = decode.TOML()
+	}
+	if err := decoder.Decode(bytes.NewReader(data), option); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	if err := decoder.Decode(bytes.NewReader(data), runnerConfig); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// fileSources marks the options whose value changed when loading the config
+// file as coming from the file.
+func fileSources[Option any](
+	before, after Option, sources OptionSources,
+) {
+	values := map[string]any{}
+	optionFields(
+		reflect.ValueOf(before), "",
+		func(name string, v reflect.Value) { values[name] = v.Interface() },
+	)
+	optionFields(
+		reflect.ValueOf(after), "",
+		func(name string, v reflect.Value) {
+			if !reflect.DeepEqual(values[name], v.Interface()) {
+				sources[name] = SourceFile
+			}
+		},
+	)
+}
+
+// printConfig writes option and runnerConfig as a single JSON document in the
+// format read by loadConfigFile.
+func printConfig(w io.Writer, option, runnerConfig any) error {
+	data, err := json.Marshal(option)
+	if err != nil {
+		return err
+	}
+	config := map[string]any{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	// the runner configuration has no json tags, use the flag names instead
+	optionFields(
+		reflect.ValueOf(runnerConfig), "",
+		func(name string, v reflect.Value) {
+			setPath(config, strings.Split(name, "."), v.Interface())
+		},
+	)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(config)
+}
+
+// setPath sets value in the nested maps of m at the given path.
+func setPath(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		nested, ok := m[key].(map[string]any)
+		if !ok {
+			nested = map[string]any{}
+			m[key] = nested
+		}
+		m = nested
+	}
+	m[path[len(path)-1]] = value
+}
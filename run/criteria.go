@@ -0,0 +1,141 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Criteria are the conditions under which the runner stops an algorithm before
+// it returns on its own. The runner cancels the algorithm context with the
+// Termination of the criterion that was met first and records it like a
+// duration limit. The target and the improvement window need solutions with a
+// value, see Valuer. The zero value stops on no criterion.
+type Criteria struct {
+	// Solutions is the maximum number of solutions. Solutions the algorithm
+	// sends after that are discarded.
+	Solutions int
+	// Target is the value at which a solution is good enough, if any.
+	Target *float64
+	// Maximize tells whether larger values are better. By default smaller
+	// values are better.
+	Maximize bool
+	// Improvement is how long the value may not improve. The window starts
+	// with the first solution with a value.
+	Improvement time.Duration
+}
+
+// parseCriteria returns the Criteria of the limits of a runner configuration.
+// An empty target means no target.
+func parseCriteria(
+	solutions int, target, objective string, improvement time.Duration,
+) (Criteria, error) {
+	criteria := Criteria{Solutions: solutions, Improvement: improvement}
+	switch objective {
+	case "", "minimize":
+	case "maximize":
+		criteria.Maximize = true
+	default:
+		return Criteria{}, errors.New(`objective must be "minimize" or "maximize"`)
+	}
+	if target != "" {
+		value, err := strconv.ParseFloat(target, 64)
+		if err != nil {
+			return Criteria{}, fmt.Errorf("target must be a number: %w", err)
+		}
+		criteria.Target = &value
+	}
+	return criteria, nil
+}
+
+// enabled reports whether any criterion is set.
+func (c Criteria) enabled() bool {
+	return c.Solutions > 0 || c.Target != nil || c.Improvement > 0
+}
+
+// criteriaChecker checks the solutions of a run against its Criteria. It is
+// used by the goroutine forwarding the solutions only, a nil checker accepts
+// all solutions.
+type criteriaChecker struct {
+	criteria Criteria
+	cancel   context.CancelCauseFunc
+	// count of the solutions so far
+	count   int
+	best    float64
+	hasBest bool
+	// stale cancels the run when the improvement window passed
+	stale *time.Timer
+}
+
+// limitCriteria derives a context that is cancelled once the solutions meet
+// the criteria of the runner configuration. It returns a nil checker if no
+// criterion is configured.
+func limitCriteria(
+	ctx context.Context, runnerConfig any,
+) (context.Context, *criteriaChecker, error) {
+	limiter, ok := runnerConfig.(CriteriaLimiter)
+	if !ok {
+		return ctx, nil, nil
+	}
+	criteria, err := limiter.Criteria()
+	if err != nil || !criteria.enabled() {
+		return ctx, nil, err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	checker := &criteriaChecker{criteria: criteria, cancel: cancel}
+	if criteria.Improvement > 0 {
+		// the window only starts with the first value
+		checker.stale = time.AfterFunc(criteria.Improvement, func() {
+			cancel(TerminationImprovement)
+		})
+		checker.stale.Stop()
+	}
+	return ctx, checker, nil
+}
+
+// accept checks the criteria against the next solution of the algorithm and
+// cancels the run if one is met. It reports false for solutions beyond the
+// solution limit, which are discarded.
+func (c *criteriaChecker) accept(solution any) bool {
+	if c == nil {
+		return true
+	}
+	c.count++
+	if c.criteria.Solutions > 0 && c.count > c.criteria.Solutions {
+		return false
+	}
+	if value, ok := solutionValue(solution); ok {
+		if c.criteria.Target != nil && !c.better(*c.criteria.Target, value) {
+			c.cancel(TerminationTarget)
+		}
+		if c.stale != nil && (!c.hasBest || c.better(value, c.best)) {
+			c.best, c.hasBest = value, true
+			c.stale.Reset(c.criteria.Improvement)
+		}
+	}
+	if c.count == c.criteria.Solutions {
+		c.cancel(TerminationSolutions)
+	}
+	return true
+}
+
+// better reports whether the value a is better than the value b.
+func (c *criteriaChecker) better(a, b float64) bool {
+	if c.criteria.Maximize {
+		return a > b
+	}
+	return a < b
+}
+
+// stop releases the resources of the checker once the run is over.
+func (c *criteriaChecker) stop() {
+	if c == nil {
+		return
+	}
+	if c.stale != nil {
+		c.stale.Stop()
+	}
+	c.cancel(nil)
+}
@@ -0,0 +1,336 @@
+package decode
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FSDecoder is a Decoder which also decodes the files of a directory.
+type FSDecoder interface {
+	Decoder
+	// DecodeFS decodes the files in fsys to the structure in the second
+	// argument of the method.
+	DecodeFS(fs.FS, any) error
+}
+
+// CSV creates a CSV decoder.
+func CSV() FSDecoder {
+	return CSVDecoder{}
+}
+
+// CSVDecoder is a Decoder that decodes CSV files into structs. The first row
+// of a file is its header. Columns are mapped onto the struct fields with the
+// same csv tag or, if there is none, the same json tag or field name, ignoring
+// case. Columns without a field are ignored. Numbers, bools, durations, times
+// in RFC3339 format and types implementing encoding.TextUnmarshaler are
+// converted, empty cells leave the zero value.
+//
+// A single file is decoded into a slice of structs, one per row. A directory
+// or a zip archive of files is decoded into a struct, whose slice fields are
+// filled from the file of the same name, e.g. stops.csv into the field with
+// the tag `csv:"stops"`. Missing files leave the field empty.
+type CSVDecoder struct{}
+
+// CSVError describes a value which cannot be decoded.
+type CSVError struct {
+	// File is the name of the file, it is empty for single files.
+	File string
+	// Row is the line of the value in the file, the header is line 1.
+	Row int
+	// Column is the header of the column of the value.
+	Column string
+	// Err is the cause of the error.
+	Err error
+}
+
+func (e CSVError) Error() string {
+	location := fmt.Sprintf("row %d, column %s", e.Row, e.Column)
+	if e.File != "" {
+		location = e.File + ": " + location
+	}
+	return location + ": " + e.Err.Error()
+}
+
+func (e CSVError) Unwrap() error {
+	return e.Err
+}
+
+// zipMagic are the first bytes of a zip archive.
+var zipMagic = []byte("PK\x03\x04")
+
+// Decode decodes CSV to the data structure v. If r holds a zip archive, its
+// files are decoded like a directory.
+func (c CSVDecoder) Decode(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(data, zipMagic) {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return err
+		}
+		return c.DecodeFS(archive, v)
+	}
+	target, err := target(v)
+	if err != nil {
+		return err
+	}
+	if target.Kind() != reflect.Slice {
+		return fmt.Errorf(
+			"cannot decode a single CSV file into %s, decode a directory "+
+				"or zip archive instead", target.Type(),
+		)
+	}
+	return decodeTable(bytes.NewReader(data), "", target)
+}
+
+// DecodeFS decodes the CSV files in fsys to the fields of the struct v. The
+// files are looked up in the root of fsys or, e.g. for an archive of a
+// directory, in a directory below.
+func (c CSVDecoder) DecodeFS(fsys fs.FS, v any) error {
+	target, err := target(v)
+	if err != nil {
+		return err
+	}
+	if target.Kind() != reflect.Struct {
+		return fmt.Errorf(
+			"cannot decode a directory into %s, it needs a struct with a "+
+				"slice for every file", target.Type(),
+		)
+	}
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		if !field.IsExported() || field.Type.Kind() != reflect.Slice {
+			continue
+		}
+		name := columnName(field)
+		if name == "-" {
+			continue
+		}
+		file, err := findFile(fsys, name+".csv")
+		if err != nil {
+			return err
+		}
+		if file == "" {
+			continue
+		}
+		if err := decodeFile(fsys, file, target.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// target returns the value v points to. v may be a pointer to an interface
+// holding a pointer, as passed by the runner.
+func target(v any) (reflect.Value, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}, errors.New("cannot decode CSV into nil")
+		}
+		value = value.Elem()
+	}
+	if !value.CanSet() {
+		return reflect.Value{}, errors.New("cannot decode CSV into a non-pointer")
+	}
+	return value, nil
+}
+
+// findFile returns the path of the file with the given name in the root of
+// fsys or in a directory below. It is empty if there is no such file.
+func findFile(fsys fs.FS, name string) (string, error) {
+	if _, err := fs.Stat(fsys, name); err == nil {
+		return name, nil
+	}
+	matches, err := fs.Glob(fsys, path.Join("*", name))
+	if err != nil || len(matches) == 0 {
+		return "", err
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("found %s more than once: %v", name, matches)
+	}
+	return matches[0], nil
+}
+
+func decodeFile(fsys fs.FS, name string, slice reflect.Value) error {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	err = decodeTable(file, name, slice)
+	return errors.Join(err, file.Close())
+}
+
+// decodeTable decodes the rows of a CSV file into the elements of slice.
+func decodeTable(r io.Reader, file string, slice reflect.Value) error {
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Pointer {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode CSV rows into %s", elemType)
+	}
+
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return csvError(file, err)
+	}
+	// field index of every column, -1 for columns without a field
+	fields := make([]int, len(header))
+	for column, name := range header {
+		fields[column] = fieldIndex(structType, strings.TrimSpace(name))
+	}
+
+	rows := reflect.MakeSlice(slice.Type(), 0, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return csvError(file, err)
+		}
+		row := reflect.New(structType).Elem()
+		for column, cell := range record {
+			if fields[column] < 0 || cell == "" {
+				continue
+			}
+			if err := setValue(row.Field(fields[column]), cell); err != nil {
+				line, _ := reader.FieldPos(column)
+				return CSVError{
+					File:   file,
+					Row:    line,
+					Column: header[column],
+					Err:    err,
+				}
+			}
+		}
+		if elemType.Kind() == reflect.Pointer {
+			row = row.Addr()
+		}
+		rows = reflect.Append(rows, row)
+	}
+	slice.Set(rows)
+	return nil
+}
+
+// csvError adds the file to the errors of the csv package, which already
+// carry the line and column.
+func csvError(file string, err error) error {
+	if file == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", file, err)
+}
+
+// fieldIndex returns the index of the field of the struct type t for the
+// column with the given name or -1.
+func fieldIndex(t reflect.Type, name string) int {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && strings.EqualFold(columnName(field), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// columnName returns the name of the column or file of a field.
+func columnName(field reflect.StructField) string {
+	for _, key := range []string{"csv", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// setValue converts the cell to the type of the field.
+func setValue(field reflect.Value, cell string) error {
+	if field.Kind() == reflect.Pointer {
+		value := reflect.New(field.Type().Elem())
+		if err := setValue(value.Elem(), cell); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		unmarshaler := field.Addr().Interface().(encoding.TextUnmarshaler)
+		return unmarshaler.UnmarshalText([]byte(cell))
+	}
+
+	switch field.Type() {
+	case durationType:
+		duration, err := time.ParseDuration(cell)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	case timeType:
+		t, err := time.Parse(time.RFC3339, cell)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64:
+		u, err := strconv.ParseUint(cell, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(cell, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot decode CSV into %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package decode_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nextmv-io/sdk/run/decode"
+)
+
+type vehicle struct {
+	ID       string        `csv:"vehicle_id"`
+	Capacity *int          `json:"capacity"`
+	Shift    time.Duration `json:"shift"`
+	Speed    float64
+}
+
+func TestCSV(t *testing.T) {
+	data := "vehicle_id,capacity,shift,speed,unknown\n" +
+		"v1,10,8h,12.5,x\n" +
+		"v2,,4h30m,,\n"
+	var vehicles []*vehicle
+	if err := decode.CSV().Decode(strings.NewReader(data), &vehicles); err != nil {
+		t.Fatal(err)
+	}
+	if len(vehicles) != 2 {
+		t.Fatalf("got %d vehicles, want 2", len(vehicles))
+	}
+	if got := vehicles[0]; got.ID != "v1" || *got.Capacity != 10 ||
+		got.Shift != 8*time.Hour || got.Speed != 12.5 {
+		t.Errorf("got %+v", got)
+	}
+	if got := vehicles[1]; got.Capacity != nil || got.Shift != 270*time.Minute {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestCSVError(t *testing.T) {
+	data := "vehicle_id,shift\nv1,8h\nv2,long\n"
+	var vehicles []vehicle
+	err := decode.CSV().Decode(strings.NewReader(data), &vehicles)
+	var csvErr decode.CSVError
+	if !errors.As(err, &csvErr) {
+		t.Fatalf("got error %v, want a CSVError", err)
+	}
+	if csvErr.Row != 3 || csvErr.Column != "shift" {
+		t.Errorf("got row %d and column %s, want 3 and shift",
+			csvErr.Row, csvErr.Column)
+	}
+}
+
+func TestCSVDecodeFS(t *testing.T) {
+	type input struct {
+		Vehicles []vehicle `json:"vehicles"`
+		Stops    []struct {
+			ID string `json:"id"`
+		} `csv:"stops"`
+		Name string
+	}
+	fsys := fstest.MapFS{
+		"plan/vehicles.csv": {Data: []byte("vehicle_id\nv1\n")},
+		"plan/stops.csv":    {Data: []byte("id\ns1\ns2\n")},
+	}
+	var in input
+	if err := decode.CSV().DecodeFS(fsys, &in); err != nil {
+		t.Fatal(err)
+	}
+	if len(in.Vehicles) != 1 || len(in.Stops) != 2 || in.Stops[1].ID != "s2" {
+		t.Errorf("got %+v", in)
+	}
+
+	fsys["plan/stops.csv"] = &fstest.MapFile{Data: []byte("id\n\"s1\n")}
+	err := decode.CSV().DecodeFS(fsys, &in)
+	if err == nil || !strings.HasPrefix(err.Error(), "plan/stops.csv: ") {
+		t.Errorf("got error %v, want it to name the file", err)
+	}
+}
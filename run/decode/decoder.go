@@ -9,3 +9,11 @@ type Decoder interface {
 	// argument of the method.
 	Decode(io.Reader, any) error
 }
+
+// JSONConverter is a Decoder of a format which can be converted to JSON, so
+// that the data can be validated against a JSON schema.
+type JSONConverter interface {
+	Decoder
+	// ToJSON reads a document from the reader and returns it as JSON.
+	ToJSON(io.Reader) ([]byte, error)
+}
@@ -0,0 +1,37 @@
+package decode
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOML creates a TOML decoder.
+func TOML() JSONConverter {
+	return TOMLDecoder{}
+}
+
+// TOMLDecoder is a Decoder that decodes a toml into a struct. Like the
+// YAMLDecoder, it converts the document to JSON first, so the json struct tags
+// of the data structure are respected. Dates and times become strings in
+// RFC3339 format.
+type TOMLDecoder struct{}
+
+// Decode decodes TOML to the data structure v.
+func (t TOMLDecoder) Decode(r io.Reader, v any) error {
+	data, err := t.ToJSON(r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &v)
+}
+
+// ToJSON reads a TOML document from r and returns it as JSON.
+func (t TOMLDecoder) ToJSON(r io.Reader) ([]byte, error) {
+	document := map[string]any{}
+	if _, err := toml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
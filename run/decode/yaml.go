@@ -0,0 +1,67 @@
+package decode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML creates a YAML decoder.
+func YAML() JSONConverter {
+	return YAMLDecoder{}
+}
+
+// YAMLDecoder is a Decoder that decodes a yaml into a struct. The document is
+// converted to JSON first, so the json struct tags of the data structure are
+// respected and types like the ones in a JSON input can be used unchanged.
+type YAMLDecoder struct{}
+
+// Decode decodes YAML to the data structure v.
+func (y YAMLDecoder) Decode(r io.Reader, v any) error {
+	data, err := YAMLToJSON(r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &v)
+}
+
+// ToJSON reads a YAML document from r and returns it as JSON.
+func (y YAMLDecoder) ToJSON(r io.Reader) ([]byte, error) {
+	return YAMLToJSON(r)
+}
+
+// YAMLToJSON reads a YAML document from r and returns it as JSON.
+func YAMLToJSON(r io.Reader) ([]byte, error) {
+	var document any
+	if err := yaml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonCompatible(document))
+}
+
+// jsonCompatible converts the mappings of a decoded YAML document, which may
+// have keys of any type, to maps with string keys.
+func jsonCompatible(document any) any {
+	switch node := document.(type) {
+	case map[string]any:
+		for key, value := range node {
+			node[key] = jsonCompatible(value)
+		}
+		return node
+	case map[any]any:
+		converted := make(map[string]any, len(node))
+		for key, value := range node {
+			converted[fmt.Sprint(key)] = jsonCompatible(value)
+		}
+		return converted
+	case []any:
+		for i, value := range node {
+			node[i] = jsonCompatible(value)
+		}
+		return node
+	default:
+		return document
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/fs"
 	"net/url"
 
 	"github.com/gorilla/schema"
@@ -18,16 +19,33 @@ func GenericDecoder[Input any](
 	return dec.Decoder
 }
 
+// errDirectory is returned for a directory input if the decoder only reads
+// single files.
+var errDirectory = errors.New(
+	"input is a directory, which only decoders of several files such as " +
+		"decode.CSV can decode",
+)
+
 type genericDecoder[Input any] struct {
 	decoder decode.Decoder
 }
 
 // Decoder is a function that decodes the input from the reader, which needs to
 // be an io.Reader. It uses the given decoder to decode the input. If the input
-// is gzipped, it will be decoded using the gzip.Reader.
+// is gzipped, it will be decoded using the gzip.Reader. A directory, given as
+// an fs.FS, can be decoded by a decode.FSDecoder.
 func (g *genericDecoder[Input]) Decoder(
 	_ context.Context, reader any) (input Input, err error,
 ) {
+	if fsys, ok := reader.(fs.FS); ok {
+		fsDecoder, ok := g.decoder.(decode.FSDecoder)
+		if !ok {
+			return input, errDirectory
+		}
+		err = fsDecoder.DecodeFS(fsys, &input)
+		return input, err
+	}
+
 	ioReader, ok := reader.(io.Reader)
 	if !ok {
 		err = errors.New(
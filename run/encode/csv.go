@@ -0,0 +1,272 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nextmv-io/sdk/flatmap"
+)
+
+// CSVOption configures a CSVEncoder.
+type CSVOption func(*CSVEncoder)
+
+// CSVPath sets the JSONPath of the array whose elements become the rows, e.g.
+// $.solutions[0].vehicles[*].route[*]. The path supports object keys, array
+// indices and the [*] wildcard. By default every solution is one row.
+func CSVPath(path string) CSVOption {
+	return func(c *CSVEncoder) {
+		c.path, c.err = parsePath(path)
+	}
+}
+
+// CSV returns a new encoder that writes CSV.
+func CSV(options ...CSVOption) Aggregator {
+	encoder := CSVEncoder{}
+	for _, option := range options {
+		option(&encoder)
+	}
+	return encoder
+}
+
+// CSVEncoder is an Encoder that encodes structs into the rows of a CSV file.
+// Every row is the JSON encoding of a value flattened with flatmap.Do, so that
+// nested fields become columns like stops[0].id. The columns are sorted by
+// name, with array indices in numeric order, and the header lists the columns
+// of all rows.
+type CSVEncoder struct {
+	path []pathSegment
+	err  error
+}
+
+// solutionColumn is the column of the index of the solution, which is added
+// if all solutions are encoded.
+const solutionColumn = "solution"
+
+// Encode writes the rows of v with a header to the w stream.
+func (c CSVEncoder) Encode(w io.Writer, v any) error {
+	rows, err := c.rows(v)
+	if err != nil {
+		return err
+	}
+	return writeCSV(w, rows, nil)
+}
+
+// EncodeAll writes the rows of all solutions with a header to the w stream.
+// The first column holds the index of the solution of every row.
+func (c CSVEncoder) EncodeAll(w io.Writer, solutions []any) error {
+	var rows []map[string]any
+	for i, solution := range solutions {
+		solutionRows, err := c.rows(solution)
+		if err != nil {
+			return err
+		}
+		for _, row := range solutionRows {
+			row[solutionColumn] = i
+		}
+		rows = append(rows, solutionRows...)
+	}
+	return writeCSV(w, rows, []string{solutionColumn})
+}
+
+// ContentType returns the content type of the encoder.
+func (c CSVEncoder) ContentType() string {
+	return "text/csv"
+}
+
+// rows flattens the values at the path of v into rows.
+func (c CSVEncoder) rows(v any) ([]map[string]any, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep numbers as they are encoded
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	values := []any{document}
+	for _, segment := range c.path {
+		values = segment.apply(values)
+	}
+	rows := make([]map[string]any, len(values))
+	for i, value := range values {
+		object, ok := value.(map[string]any)
+		if !ok {
+			object = map[string]any{"value": value}
+		}
+		rows[i] = flatmap.Do(object, flatmap.Options{})
+	}
+	return rows, nil
+}
+
+// writeCSV writes the header and the rows. The leading columns come first,
+// followed by all other columns of the rows in order.
+func writeCSV(w io.Writer, rows []map[string]any, leading []string) error {
+	columns := map[string]bool{}
+	for _, row := range rows {
+		for column := range row {
+			columns[column] = true
+		}
+	}
+	for _, column := range leading {
+		delete(columns, column)
+	}
+	sorted := make([]string, 0, len(columns))
+	for column := range columns {
+		sorted = append(sorted, column)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return naturalLess(sorted[i], sorted[j])
+	})
+	header := append(append([]string{}, leading...), sorted...)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(header))
+	for _, row := range rows {
+		for i, column := range header {
+			record[i] = cell(row[column])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// cell formats a flattened value. Missing values and null are empty.
+func cell(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []any:
+		// only empty arrays remain after flattening
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// naturalLess compares the column names a and b, with numbers in order of
+// their value, so that a[2] comes before a[10].
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		aDigits, bDigits := leadingDigits(a), leadingDigits(b)
+		if aDigits != "" && bDigits != "" {
+			aNumber, _ := strconv.Atoi(aDigits)
+			bNumber, _ := strconv.Atoi(bDigits)
+			if aNumber != bNumber {
+				return aNumber < bNumber
+			}
+			a, b = a[len(aDigits):], b[len(bDigits):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func leadingDigits(s string) string {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	return s[:end]
+}
+
+// pathSegment is a step of a JSONPath: an object key, an array index or, if
+// wildcard is set, all elements of an array.
+type pathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// apply returns the values selected by the segment from all values. Values
+// without a match are left out.
+func (s pathSegment) apply(values []any) []any {
+	var selected []any
+	for _, value := range values {
+		switch {
+		case s.wildcard:
+			if array, ok := value.([]any); ok {
+				selected = append(selected, array...)
+			}
+		case s.isIndex:
+			if array, ok := value.([]any); ok && s.index < len(array) {
+				selected = append(selected, array[s.index])
+			}
+		default:
+			if object, ok := value.(map[string]any); ok {
+				if child, ok := object[s.key]; ok {
+					selected = append(selected, child)
+				}
+			}
+		}
+	}
+	return selected
+}
+
+// parsePath parses a JSONPath like $.solutions[0].vehicles[*].
+func parsePath(path string) ([]pathSegment, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, fmt.Errorf("path %q must start with $", path)
+	}
+	var segments []pathSegment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+			segments = append(segments, pathSegment{key: rest[1:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unclosed [", path)
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			if selector == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+				continue
+			}
+			index, err := strconv.Atoi(selector)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf(
+					"path %q has an invalid index %q", path, selector,
+				)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("path %q is invalid at %q", path, rest)
+		}
+	}
+	return segments, nil
+}
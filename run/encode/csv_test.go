@@ -0,0 +1,88 @@
+package encode_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nextmv-io/sdk/run/encode"
+)
+
+type stop struct {
+	ID       string  `json:"id"`
+	Duration *int    `json:"duration"`
+	Position []int   `json:"position"`
+	Cost     float64 `json:"cost"`
+}
+
+type route struct {
+	Vehicle string `json:"vehicle"`
+	Stops   []stop `json:"stops"`
+}
+
+type solution struct {
+	Routes []route `json:"routes"`
+}
+
+func TestCSV(t *testing.T) {
+	duration := 5
+	var b strings.Builder
+	err := encode.CSV().Encode(&b, stop{
+		ID:       "s1",
+		Duration: &duration,
+		Position: []int{7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17},
+		Cost:     0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "cost,duration,id,position[0],position[1],position[2]," +
+		"position[3],position[4],position[5],position[6],position[7]," +
+		"position[8],position[9],position[10]\n" +
+		"0.5,5,s1,7,8,9,10,11,12,13,14,15,16,17\n"
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCSVPath(t *testing.T) {
+	solutions := []any{
+		solution{Routes: []route{
+			{Vehicle: "v1", Stops: []stop{{ID: "s1"}, {ID: "s2"}}},
+			{Vehicle: "v2", Stops: []stop{{ID: "s3", Cost: 1}}},
+		}},
+		solution{Routes: []route{
+			{Vehicle: "v1", Stops: []stop{{ID: "s3", Position: []int{1}}}},
+		}},
+	}
+	encoder := encode.CSV(encode.CSVPath("$.routes[*].stops[*]"))
+	var b strings.Builder
+	if err := encoder.EncodeAll(&b, solutions); err != nil {
+		t.Fatal(err)
+	}
+	want := "solution,cost,duration,id,position,position[0]\n" +
+		"0,0,,s1,,\n" +
+		"0,0,,s2,,\n" +
+		"0,1,,s3,,\n" +
+		"1,0,,s3,,1\n"
+	if got := b.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	encoder = encode.CSV(encode.CSVPath("$.routes[1].vehicle"))
+	if err := encoder.Encode(&b, solutions[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := b.String(), "value\nv2\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCSVPathInvalid(t *testing.T) {
+	for _, path := range []string{"routes", "$.routes[", "$.routes[x]", "$..id"} {
+		err := encode.CSV(encode.CSVPath(path)).Encode(&strings.Builder{}, nil)
+		if err == nil {
+			t.Errorf("path %q: got no error", path)
+		}
+	}
+}
@@ -8,3 +8,25 @@ type Encoder interface {
 	// Encode encodes the data to the
 	Encode(io.Writer, any) error
 }
+
+// Aggregator is an Encoder which encodes all solutions of a run at once, so
+// that they form a single document. It is used instead of Encode if all
+// solutions are requested. Streaming encoders, such as NDJSON and SSE, are no
+// Aggregators and write every solution as soon as it is found.
+type Aggregator interface {
+	Encoder
+	// EncodeAll encodes the solutions to the writer.
+	EncodeAll(io.Writer, []any) error
+}
+
+// Flush flushes w if it supports flushing, e.g. a bufio.Writer, a gzip.Writer
+// or an http.ResponseWriter. Other writers are left untouched.
+func Flush(w io.Writer) error {
+	switch f := w.(type) {
+	case interface{ Flush() error }:
+		return f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package encode
 import (
 	"encoding/json"
 	"io"
+
+	"github.com/nextmv-io/sdk/run/schema"
 )
 
 // JSON returns a new encoder that writes JSON.
@@ -19,6 +21,12 @@ func (j JSONEncoder) Encode(w io.Writer, v any) error {
 	return json.NewEncoder(w).Encode(v)
 }
 
+// EncodeAll writes all solutions as a single JSON document, which has the
+// shape of a schema.Output, see schema.Aggregate.
+func (j JSONEncoder) EncodeAll(w io.Writer, solutions []any) error {
+	return j.Encode(w, schema.Aggregate(solutions))
+}
+
 // ContentType returns the content type of the encoder.
 func (j JSONEncoder) ContentType() string {
 	return "application/json"
@@ -0,0 +1,30 @@
+package encode
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// NDJSON returns a new encoder that writes newline delimited JSON. Every value
+// is written as a single line and flushed right away, which makes it suitable
+// for streaming solutions.
+func NDJSON() Encoder {
+	return NDJSONEncoder{}
+}
+
+// NDJSONEncoder is a Encoder that encodes a struct into a line of JSON.
+type NDJSONEncoder struct{}
+
+// Encode writes the JSON encoding of v to the w stream, followed by a newline
+// character, and flushes w if it supports flushing.
+func (n NDJSONEncoder) Encode(w io.Writer, v any) error {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		return err
+	}
+	return Flush(w)
+}
+
+// ContentType returns the content type of the encoder.
+func (n NDJSONEncoder) ContentType() string {
+	return "application/x-ndjson"
+}
@@ -0,0 +1,37 @@
+package encode
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SSE returns a new encoder that writes Server-Sent Events. Every value is
+// written as the JSON data of one event and flushed right away.
+func SSE() Encoder {
+	return SSEEncoder{}
+}
+
+// SSEEncoder is a Encoder that encodes a struct into a Server-Sent Event.
+type SSEEncoder struct{}
+
+// Encode writes v as a JSON data event to the w stream and flushes w if it
+// supports flushing.
+func (s SSEEncoder) Encode(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	event := make([]byte, 0, len(data)+8)
+	event = append(event, "data: "...)
+	event = append(event, data...)
+	event = append(event, "\n\n"...)
+	if _, err := w.Write(event); err != nil {
+		return err
+	}
+	return Flush(w)
+}
+
+// ContentType returns the content type of the encoder.
+func (s SSEEncoder) ContentType() string {
+	return "text/event-stream"
+}
@@ -0,0 +1,88 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+// TOML returns a new encoder that writes TOML.
+func TOML() Encoder {
+	return TOMLEncoder{}
+}
+
+// TOMLEncoder is an Encoder that encodes a struct into a toml. The struct is
+// encoded as JSON first, so its json struct tags are respected. The keys of a
+// table are sorted by name. TOML has no null, so values which are null in
+// JSON are left out. Only structs and maps can be encoded, as a TOML document
+// is a table.
+type TOMLEncoder struct{}
+
+// Encode writes the TOML encoding of v to the w stream.
+func (t TOMLEncoder) Encode(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep integers, which would become floats otherwise
+	decoder.UseNumber()
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return err
+	}
+	table, ok := tomlValue(document).(map[string]any)
+	if !ok {
+		return fmt.Errorf("cannot encode %T as TOML, it needs a table", v)
+	}
+	encoder := toml.NewEncoder(w)
+	encoder.Indent = ""
+	return encoder.Encode(table)
+}
+
+// EncodeAll writes all solutions as a single TOML document, which has the
+// shape of a schema.Output, see schema.Aggregate.
+func (t TOMLEncoder) EncodeAll(w io.Writer, solutions []any) error {
+	return t.Encode(w, schema.Aggregate(solutions))
+}
+
+// ContentType returns the content type of the encoder.
+func (t TOMLEncoder) ContentType() string {
+	return "application/toml"
+}
+
+// tomlValue converts numbers to integers or floats and removes null values
+// from a decoded JSON document.
+func tomlValue(document any) any {
+	switch value := document.(type) {
+	case map[string]any:
+		for key, child := range value {
+			if child == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = tomlValue(child)
+		}
+		return value
+	case []any:
+		values := make([]any, 0, len(value))
+		for _, child := range value {
+			if child != nil {
+				values = append(values, tomlValue(child))
+			}
+		}
+		return values
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	default:
+		return value
+	}
+}
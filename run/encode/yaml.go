@@ -0,0 +1,60 @@
+package encode
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/nextmv-io/sdk/run/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// YAML returns a new encoder that writes YAML.
+func YAML() Encoder {
+	return YAMLEncoder{}
+}
+
+// YAMLEncoder is an Encoder that encodes a struct into a yaml. The struct is
+// encoded as JSON first, so its json struct tags are respected and the fields
+// keep their order.
+type YAMLEncoder struct{}
+
+// Encode writes the YAML encoding of v to the w stream.
+func (y YAMLEncoder) Encode(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// JSON is valid YAML, so it can be parsed into nodes, which keep the
+	// order of the keys and the exact numbers
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	blockStyle(&document)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// EncodeAll writes all solutions as a single YAML document, which has the
+// shape of a schema.Output, see schema.Aggregate.
+func (y YAMLEncoder) EncodeAll(w io.Writer, solutions []any) error {
+	return y.Encode(w, schema.Aggregate(solutions))
+}
+
+// ContentType returns the content type of the encoder.
+func (y YAMLEncoder) ContentType() string {
+	return "application/yaml"
+}
+
+// blockStyle resets the flow style and the quotes of the JSON the node was
+// parsed from. Strings are still quoted if they would be read as another type.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
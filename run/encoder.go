@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net/http"
 	"strings"
 
 	"github.com/nextmv-io/sdk/run/encode"
@@ -23,11 +24,18 @@ type genericEncoder[Solution, Options any] struct {
 	encoder encode.Encoder
 }
 
-// Encode encodes the solution using the given encoder. If a given output path
-// ends in .gz, it will be gzipped after encoding. The writer needs to be an
-// io.Writer.
+// Encode encodes the solution using the given encoder. If all solutions are
+// requested and the encoder is an encode.Aggregator, they are encoded at once.
+// If a given output path ends in .gz, it will be gzipped after encoding. The
+// values of the solutions, see Valuer, are added to the final schema.Output
+// solution and, if the runner configuration is a TimingsReporter, the Timings
+// of the run to every schema.Output solution. Solutions which are streamed to
+// an http.Flusher are sent as soon as they are found, before it is known
+// whether they are final, so none of them gets the values. The writer needs
+// to be an io.Writer. If it is an io.Closer, it is closed once all solutions
+// are encoded.
 func (g *genericEncoder[Solution, Options]) Encode(
-	_ context.Context,
+	ctx context.Context,
 	solutions <-chan Solution,
 	writer any,
 	runnerCfg any,
@@ -52,10 +60,30 @@ func (g *genericEncoder[Solution, Options]) Encode(
 
 	if outputPather, ok := runnerCfg.(OutputPather); ok {
 		if strings.HasSuffix(outputPather.OutputPath(), ".gz") {
-			ioWriter = gzip.NewWriter(ioWriter)
+			gzipWriter := gzip.NewWriter(ioWriter)
+			// close before the writer to flush the compressed data
+			defer func() {
+				tempErr := gzipWriter.Close()
+				if err == nil {
+					err = tempErr
+				}
+			}()
+			ioWriter = gzipWriter
 		}
 	}
 
+	reporter, ok := runnerCfg.(TimingsReporter)
+	timings := ok && reporter.ReportTimings()
+	report := func(solution Solution, final bool) Solution {
+		if final {
+			solution = reportValues(ctx, solution)
+		}
+		if timings {
+			solution = reportTimings(ctx, solution)
+		}
+		return solution
+	}
+
 	if limiter, ok := runnerCfg.(SolutionLimiter); ok {
 		solutionFlag, retErr := limiter.Solutions()
 		if retErr != nil {
@@ -77,13 +105,58 @@ func (g *genericEncoder[Solution, Options]) Encode(
 			close(tempSolutions)
 			solutions = tempSolutions
 		}
+
+		// encoders which aggregate all solutions into a single document
+		// encode them at once
+		if aggregator, ok := g.encoder.(encode.Aggregator); ok &&
+			solutionFlag == All {
+			var all []Solution
+			for solution := range solutions {
+				all = append(all, solution)
+			}
+			reported := make([]any, len(all))
+			for i, solution := range all {
+				reported[i] = report(solution, i == len(all)-1)
+			}
+			return aggregator.EncodeAll(ioWriter, reported)
+		}
 	}
 
-	for solution := range solutions {
-		err := g.encoder.Encode(ioWriter, solution)
-		if err != nil {
+	flusher, streaming := writer.(http.Flusher)
+	encodeSolution := func(solution Solution, final bool) error {
+		err := g.encoder.Encode(ioWriter, report(solution, final))
+		if err != nil || !streaming {
 			return err
 		}
+		// send every solution to the client right away, so that it can be
+		// streamed by a http.ResponseWriter
+		if err := encode.Flush(ioWriter); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	// unless the solutions are streamed, each one is held back until the
+	// next one arrives, so that the final one is known
+	var pending Solution
+	hasPending := false
+	for solution := range solutions {
+		if streaming {
+			if err := encodeSolution(solution, false); err != nil {
+				return err
+			}
+			continue
+		}
+		if hasPending {
+			if err := encodeSolution(pending, false); err != nil {
+				return err
+			}
+		}
+		pending, hasPending = solution, true
+	}
+	if hasPending {
+		return encodeSolution(pending, true)
 	}
 	return nil
 }
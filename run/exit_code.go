@@ -0,0 +1,69 @@
+package run
+
+import (
+	"errors"
+
+	"github.com/nextmv-io/sdk/run/validate"
+)
+
+// Exit codes used for errors that do not define their own.
+const (
+	// ExitCodeSuccess is the exit code of a successful run.
+	ExitCodeSuccess = 0
+	// ExitCodeFailure is the exit code of a failed run.
+	ExitCodeFailure = 1
+	// ExitCodeInvalidInput is the exit code of a run whose input does not
+	// satisfy the schema, see validate.Error. It is EX_DATAERR of sysexits.h.
+	ExitCodeInvalidInput = 65
+)
+
+// ExitCoder is the interface an error can implement to define the exit code
+// of the process.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// ExitCode returns the exit code for the error returned by a runner. It is
+// ExitCodeSuccess for nil, the code of the first ExitCoder in the error's
+// chain, ExitCodeInvalidInput for a validate.Error or a DecodeError, or
+// ExitCodeFailure otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var exitCoder ExitCoder
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+	var validationErr validate.Error
+	var decodeErr DecodeError
+	if errors.As(err, &validationErr) || errors.As(err, &decodeErr) {
+		return ExitCodeInvalidInput
+	}
+	return ExitCodeFailure
+}
+
+// DecodeError is returned by a runner if the input or the option of a run
+// cannot be decoded, e.g. because they are malformed.
+type DecodeError struct {
+	Err error
+}
+
+func (e DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError wraps an error of a decoder in a DecodeError, unless it already
+// is a client error.
+func decodeError(err error) error {
+	var validationErr validate.Error
+	var decodeErr DecodeError
+	if errors.As(err, &validationErr) || errors.As(err, &decodeErr) {
+		return err
+	}
+	return DecodeError{Err: err}
+}
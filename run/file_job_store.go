@@ -0,0 +1,188 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewFileJobStore creates a JobStore which keeps every job in dir as
+// <id>.json with its encoded output in <id>.result, so that jobs survive a
+// restart of the runner. The directory is created if it does not exist.
+// Finished jobs expire ttl after they finished, a ttl of zero keeps them
+// forever. Queued and running jobs never expire.
+func NewFileJobStore(dir string, ttl time.Duration) (JobStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &fileJobStore{dir: dir, ttl: ttl}, nil
+}
+
+// expireInterval is the longest time between two scans of the directory for
+// expired jobs.
+const expireInterval = time.Minute
+
+type fileJobStore struct {
+	dir   string
+	ttl   time.Duration
+	mutex sync.Mutex
+	// scanned is the time of the last scan for expired jobs
+	scanned time.Time
+}
+
+func (s *fileJobStore) Save(_ context.Context, job Job) error {
+	path, err := s.path(job.ID, ".json")
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.expire(); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+func (s *fileJobStore) Job(_ context.Context, id string) (Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.job(id)
+}
+
+func (s *fileJobStore) SaveResult(
+	_ context.Context, id string, result []byte,
+) error {
+	path, err := s.path(id, ".result")
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.job(id); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, result)
+}
+
+func (s *fileJobStore) Result(_ context.Context, id string) ([]byte, error) {
+	path, err := s.path(id, ".result")
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.job(id); err != nil {
+		return nil, err
+	}
+	result, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrJobNotFound
+	}
+	return result, err
+}
+
+func (s *fileJobStore) Delete(_ context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.delete(id)
+}
+
+// job reads the job with the given id and removes it if it is expired. The
+// mutex must be held.
+func (s *fileJobStore) job(id string) (Job, error) {
+	path, err := s.path(id, ".json")
+	if err != nil {
+		return Job{}, err
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Job{}, ErrJobNotFound
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return Job{}, err
+	}
+	if expired(job, s.ttl) {
+		return Job{}, errors.Join(ErrJobNotFound, s.delete(id))
+	}
+	return job, nil
+}
+
+// expire removes all expired jobs, unless the directory was scanned recently.
+// Expired jobs which are looked up before are removed by job. The mutex must
+// be held.
+func (s *fileJobStore) expire() error {
+	if s.ttl <= 0 || time.Since(s.scanned) < min(s.ttl, expireInterval) {
+		return nil
+	}
+	s.scanned = time.Now()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		// job removes expired jobs as a side effect
+		if _, err := s.job(id); err != nil && !errors.Is(err, ErrJobNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// delete removes the files of the job with the given id. The mutex must be
+// held.
+func (s *fileJobStore) delete(id string) error {
+	for _, extension := range []string{".json", ".result"} {
+		path, err := s.path(id, extension)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// path returns the path of a file of the job with the given id. Ids which
+// could point outside of the directory do not belong to any job.
+func (s *fileJobStore) path(id, extension string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", ErrJobNotFound
+	}
+	return filepath.Join(s.dir, id+extension), nil
+}
+
+// writeFileAtomic writes data to a temporary file which is renamed to path,
+// so that readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return errors.Join(err, file.Close(), os.Remove(file.Name()))
+	}
+	if err := file.Close(); err != nil {
+		return errors.Join(err, os.Remove(file.Name()))
+	}
+	return os.Rename(file.Name(), path)
+}
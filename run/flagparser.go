@@ -1,20 +1,113 @@
 package run
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 
 	"github.com/itzg/go-flagsfiller"
 )
 
+// ErrConfigPrinted is returned by ParseFlags when the -runner.print.config flag
+// is given and the flag set does not exit on errors, like flag.ErrHelp is
+// returned for the -help flag.
+var ErrConfigPrinted = errors.New("run: configuration printed")
+
 // FlagParser parses flags and env vars and returns a runner config and options.
+// If the -runner.config flag is given, the values in that file are applied on
+// top of the flags. If the -runner.print.config flag is given, the resolved
+// configuration is printed as JSON and the program exits. FlagParser defines
+// the flags on flag.CommandLine, use ParseFlags to parse flags in isolation.
 func FlagParser[Option, RunnerCfg any]() (
 	runnerConfig RunnerCfg, option Option, err error,
 ) {
+	runnerConfig, option, _, err = ParseFlags[Option, RunnerCfg](
+		flag.CommandLine, os.Args[1:],
+	)
+	return runnerConfig, option, err
+}
+
+// ParseFlags parses args and env vars like FlagParser and additionally returns
+// the source of every option value. The flags are defined on fs, so a flag set
+// can only be used for a single call. Errors are handled according to the
+// error handling of fs: with flag.ExitOnError the program exits after printing
+// the usage or the configuration, otherwise flag.ErrHelp or ErrConfigPrinted
+// are returned.
+func ParseFlags[Option, RunnerCfg any](fs *flag.FlagSet, args []string) (
+	runnerConfig RunnerCfg, option Option, sources OptionSources, err error,
+) {
+	// resolve the lower layers separately to tell where values come from
+	var defaultOption, envOption Option
+	err = newFiller(flagsfiller.NoSetFromEnv()).Fill(
+		flag.NewFlagSet("default", flag.ContinueOnError), &defaultOption,
+	)
+	if err != nil {
+		return runnerConfig, option, nil, err
+	}
+	err = newFiller().Fill(
+		flag.NewFlagSet("env", flag.ContinueOnError), &envOption,
+	)
+	if err != nil {
+		return runnerConfig, option, nil, err
+	}
+
 	// create a FlagSetFiller
-	filler := flagsfiller.New(
+	filler := newFiller()
+	err = filler.Fill(fs, &option)
+	if err != nil {
+		return runnerConfig, option, nil, err
+	}
+
+	err = filler.Fill(fs, &runnerConfig)
+	if err != nil {
+		return runnerConfig, option, nil, err
+	}
+
+	var config configFlags
+	err = filler.Fill(fs, &config)
+	if err != nil {
+		return runnerConfig, option, nil, err
+	}
+	fs.Usage = usage(fs)
+	err = fs.Parse(args)
+	if err != nil {
+		return runnerConfig, option, nil, err
+	}
+
+	flags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { flags[f.Name] = true })
+	sources = flagSources(defaultOption, envOption, flags)
+
+	if config.Runner.Config != "" {
+		flagOption := option
+		err = loadConfigFile(config.Runner.Config, &option, &runnerConfig)
+		if err != nil {
+			return runnerConfig, option, nil, err
+		}
+		fileSources(flagOption, option, sources)
+	}
+
+	if config.Runner.Print.Config {
+		if err := printConfig(os.Stdout, option, runnerConfig); err != nil {
+			return runnerConfig, option, nil, err
+		}
+		if fs.ErrorHandling() == flag.ExitOnError {
+			os.Exit(0)
+		}
+		return runnerConfig, option, nil, ErrConfigPrinted
+	}
+
+	return runnerConfig, option, sources, nil
+}
+
+// newFiller creates a FlagSetFiller that reads env vars and names flags after
+// the lower cased field path, e.g. runner.output.path.
+func newFiller(options ...flagsfiller.FillerOption) *flagsfiller.FlagSetFiller {
+	options = append(
+		options,
 		flagsfiller.WithEnv(""),
 		flagsfiller.WithFieldRenamer(
 			func(name string) string {
@@ -23,29 +116,47 @@ func FlagParser[Option, RunnerCfg any]() (
 			},
 		),
 	)
-	err = filler.Fill(flag.CommandLine, &option)
-	if err != nil {
-		return runnerConfig, option, err
-	}
-
-	err = filler.Fill(flag.CommandLine, &runnerConfig)
-	if err != nil {
-		return runnerConfig, option, err
-	}
-	flag.Usage = usage
-	flag.Parse()
+	return flagsfiller.New(options...)
+}
 
-	return runnerConfig, option, nil
+// flagSources determines the source of every field of option. Fields set by a
+// flag come from the flag, fields that differ from their default come from an
+// env var and all other fields come from the default.
+func flagSources[Option any](
+	defaultOption, envOption Option, flags map[string]bool,
+) OptionSources {
+	defaults := map[string]any{}
+	optionFields(
+		reflect.ValueOf(defaultOption), "",
+		func(name string, v reflect.Value) { defaults[name] = v.Interface() },
+	)
+	sources := OptionSources{}
+	optionFields(
+		reflect.ValueOf(envOption), "",
+		func(name string, v reflect.Value) {
+			switch {
+			case flags[name]:
+				sources[name] = SourceFlag
+			case !reflect.DeepEqual(defaults[name], v.Interface()):
+				sources[name] = SourceEnv
+			default:
+				sources[name] = SourceDefault
+			}
+		},
+	)
+	return sources
 }
 
-func usage() {
-	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
-	out := fs.Output()
+// usage returns the usage function of fs.
+func usage(fs *flag.FlagSet) func() {
+	return func() {
+		out := fs.Output()
 
-	fmt.Fprint(
-		out,
-		"Nextmv Hybrid Optimization Platform\n",
-	)
-	fmt.Fprint(out, "Usage:\n")
-	flag.PrintDefaults()
+		fmt.Fprint(
+			out,
+			"Nextmv Hybrid Optimization Platform\n",
+		)
+		fmt.Fprint(out, "Usage:\n")
+		fs.PrintDefaults()
+	}
 }
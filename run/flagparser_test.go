@@ -0,0 +1,169 @@
+package run_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
+type testOption struct {
+	Duration time.Duration `json:"duration" default:"1s"`
+	Solve    struct {
+		Iterations int `json:"iterations" default:"10"`
+		Seed       int `json:"seed"`
+	} `json:"solve"`
+}
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		env        map[string]string
+		iterations int
+		seed       int
+		duration   time.Duration
+		sources    run.OptionSources
+		err        error
+	}{
+		{
+			name:       "defaults",
+			iterations: 10,
+			duration:   time.Second,
+			sources: run.OptionSources{
+				"duration":         run.SourceDefault,
+				"solve.iterations": run.SourceDefault,
+				"solve.seed":       run.SourceDefault,
+			},
+		},
+		{
+			name:       "flags and env",
+			args:       []string{"-solve.iterations", "20"},
+			env:        map[string]string{"SOLVE_SEED": "7"},
+			iterations: 20,
+			seed:       7,
+			duration:   time.Second,
+			sources: run.OptionSources{
+				"duration":         run.SourceDefault,
+				"solve.iterations": run.SourceFlag,
+				"solve.seed":       run.SourceEnv,
+			},
+		},
+		{
+			name: "unknown flag",
+			args: []string{"-solve.unknown", "1"},
+			err:  errors.New("flag provided but not defined: -solve.unknown"),
+		},
+		{
+			name: "help",
+			args: []string{"-help"},
+			err:  flag.ErrHelp,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			runnerConfig, option, sources, err := run.ParseFlags[
+				testOption, run.CLIRunnerConfig,
+			](newFlagSet(), test.args)
+			if test.err != nil {
+				if err == nil || err.Error() != test.err.Error() {
+					t.Fatalf("got error %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if option.Solve.Iterations != test.iterations ||
+				option.Solve.Seed != test.seed ||
+				option.Duration != test.duration {
+				t.Errorf("got option %+v", option)
+			}
+			if !reflect.DeepEqual(sources, test.sources) {
+				t.Errorf("got sources %v, want %v", sources, test.sources)
+			}
+			if runnerConfig.Runner.Output.Solutions != "last" {
+				t.Errorf("got runner config %+v", runnerConfig)
+			}
+		})
+	}
+}
+
+func TestNewCLIRunnerFromFlags(t *testing.T) {
+	algorithm := func(
+		_ context.Context, _ any, _ testOption, _ chan<- any,
+	) error {
+		return nil
+	}
+
+	// two runners in one process do not share flags
+	first, err := run.NewCLIRunnerFromFlags(
+		newFlagSet(), []string{"-runner.output.path", "first.json"}, algorithm,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := run.NewCLIRunnerFromFlags(
+		newFlagSet(), []string{"-runner.output.path", "second.json"}, algorithm,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.RunnerConfig().OutputPath() != "first.json" ||
+		second.RunnerConfig().OutputPath() != "second.json" {
+		t.Errorf(
+			"got output paths %q and %q",
+			first.RunnerConfig().OutputPath(),
+			second.RunnerConfig().OutputPath(),
+		)
+	}
+
+	// a supplied configuration skips flag parsing altogether
+	var config run.CLIRunnerConfig
+	config.Runner.Output.Path = "config.json"
+	configured, err := run.NewCLIRunnerFromFlags(
+		newFlagSet(), []string{"-not.a.flag"}, algorithm,
+		run.Configure[run.CLIRunnerConfig, any, testOption, any](config, testOption{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configured.RunnerConfig().OutputPath() != "config.json" {
+		t.Errorf("got output path %q", configured.RunnerConfig().OutputPath())
+	}
+
+	_, err = run.NewCLIRunnerFromFlags(
+		newFlagSet(), []string{"-runner.print.config"}, algorithm,
+	)
+	if !errors.Is(err, run.ErrConfigPrinted) {
+		t.Errorf("got error %v, want %v", err, run.ErrConfigPrinted)
+	}
+
+	// invalid criteria are reported by the constructor, not by every run
+	_, err = run.NewCLIRunnerFromFlags(
+		newFlagSet(), []string{"-runner.limits.target", "low"}, algorithm,
+	)
+	if err == nil {
+		t.Error("got no error for an invalid target")
+	}
+	_, err = run.NewHTTPRunnerFromFlags(
+		newFlagSet(), []string{"-runner.limits.objective", "best"}, algorithm,
+	)
+	if err == nil {
+		t.Error("got no error for an invalid objective")
+	}
+}
@@ -2,6 +2,9 @@ package run
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"reflect"
@@ -13,14 +16,22 @@ import (
 
 type start string
 type data string
+type sources string
 
-// Start is the key for the start time of the run.
+// Start is the key for the start time of the run. The durations of the steps
+// of the run are available with TimingsOf.
 const Start start = "start"
 
 // Data is the key for additional data of the run.
 const Data data = "data"
 
-// GenericRunner creates a new runner from the given components.
+// Sources is the key for the OptionSources of the run, which tell the layer
+// that supplied the value of every option.
+const Sources sources = "sources"
+
+// GenericRunner creates a new runner from the given components. The runner
+// configuration and the options are parsed from the command line, the program
+// exits if that fails.
 func GenericRunner[RunnerConfig, Input, Option, Solution any](
 	ioHandler IOProducer[RunnerConfig],
 	inputDecoder Decoder[Input],
@@ -29,21 +40,68 @@ func GenericRunner[RunnerConfig, Input, Option, Solution any](
 	handler Algorithm[Input, Option, Solution],
 	encoder Encoder[Solution, Option],
 ) Runner[RunnerConfig, Input, Option, Solution] {
-	runnerConfig, option, err := FlagParser[
-		Option, RunnerConfig,
-	]()
-	if err != nil {
+	runner := newGenericRunner(
+		ioHandler,
+		inputDecoder,
+		inputValidator,
+		optionDecoder,
+		handler,
+		encoder,
+	)
+	if err := runner.configure(flag.CommandLine, os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
+	return runner
+}
+
+// NewGenericRunner creates a new runner from the given components and parses
+// its configuration from args with fs, see ParseFlags. The options are applied
+// before the flags are parsed, so that no flags are parsed at all if the
+// configuration is supplied with Configure.
+func NewGenericRunner[RunnerConfig, Input, Option, Solution any](
+	fs *flag.FlagSet,
+	args []string,
+	ioHandler IOProducer[RunnerConfig],
+	inputDecoder Decoder[Input],
+	inputValidator Validator[Input],
+	optionDecoder Decoder[Option],
+	handler Algorithm[Input, Option, Solution],
+	encoder Encoder[Solution, Option],
+	options ...RunnerOption[RunnerConfig, Input, Option, Solution],
+) (Runner[RunnerConfig, Input, Option, Solution], error) {
+	runner := newGenericRunner(
+		ioHandler,
+		inputDecoder,
+		inputValidator,
+		optionDecoder,
+		handler,
+		encoder,
+	)
+	for _, option := range options {
+		option(runner)
+	}
+	if err := runner.configure(fs, args); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+// newGenericRunner creates a runner which is not configured yet.
+func newGenericRunner[RunnerConfig, Input, Option, Solution any](
+	ioHandler IOProducer[RunnerConfig],
+	inputDecoder Decoder[Input],
+	inputValidator Validator[Input],
+	optionDecoder Decoder[Option],
+	handler Algorithm[Input, Option, Solution],
+	encoder Encoder[Solution, Option],
+) *genericRunner[RunnerConfig, Input, Option, Solution] {
 	return &genericRunner[RunnerConfig, Input, Option, Solution]{
-		IOProducer:       ioHandler,
-		InputDecoder:     inputDecoder,
-		InputValidator:   inputValidator,
-		OptionDecoder:    optionDecoder,
-		Algorithm:        handler,
-		Encoder:          encoder,
-		runnerConfig:     runnerConfig,
-		flagParsedOption: option,
+		IOProducer:     ioHandler,
+		InputDecoder:   inputDecoder,
+		InputValidator: inputValidator,
+		OptionDecoder:  optionDecoder,
+		Algorithm:      handler,
+		Encoder:        encoder,
 	}
 }
 
@@ -56,6 +114,37 @@ type genericRunner[RunnerConfig, Input, Option, Solution any] struct {
 	Encoder          Encoder[Solution, Option]
 	runnerConfig     RunnerConfig
 	flagParsedOption Option
+	optionSources    OptionSources
+	configured       bool
+	// defaultValidator tells whether the InputValidator is the JSON schema
+	// validation the runner was created with, see InputDecodeFormat.
+	defaultValidator bool
+}
+
+// configure parses the runner configuration and the options from args with
+// fs, unless they were already set with SetConfig. Criteria which cannot be
+// parsed are reported here rather than by every run.
+func (r *genericRunner[RunnerConfig, Input, Option, Solution]) configure(
+	fs *flag.FlagSet, args []string,
+) error {
+	if !r.configured {
+		runnerConfig, option, optionSources, err := ParseFlags[
+			Option, RunnerConfig,
+		](fs, args)
+		if err != nil {
+			return err
+		}
+		r.runnerConfig = runnerConfig
+		r.flagParsedOption = option
+		r.optionSources = optionSources
+		r.configured = true
+	}
+	if limiter, ok := any(r.runnerConfig).(CriteriaLimiter); ok {
+		if _, err := limiter.Criteria(); err != nil {
+			return fmt.Errorf("invalid limits: %w", err)
+		}
+	}
+	return nil
 }
 
 func (r *genericRunner[RunnerConfig, Input, Option, Solution]) handleCPUProfile(
@@ -117,10 +206,26 @@ func (r *genericRunner[RunnerConfig, Input, Option, Solution],
 
 func (r *genericRunner[RunnerConfig, Input, Option, Solution]) Run(
 	ctx context.Context,
+) error {
+	return r.RunWith(ctx, r.IOProducer)
+}
+
+func (r *genericRunner[RunnerConfig, Input, Option, Solution]) RunWith(
+	ctx context.Context, ioProducer IOProducer[RunnerConfig],
 ) (retErr error) {
 	start := time.Now()
 	ctx = context.WithValue(ctx, Start, start)
 	ctx = context.WithValue(ctx, Data, &sync.Map{})
+	ctx = withTimer(ctx)
+	// limit the duration of the run
+	ctx, cancel := limitDuration(ctx, start, r.runnerConfig)
+	defer cancel()
+	// stop the run early once its solutions meet the criteria
+	ctx, criteria, retErr := limitCriteria(ctx, r.runnerConfig)
+	if retErr != nil {
+		return retErr
+	}
+	defer criteria.stop()
 	// handle CPU profile
 	deferFuncCPU, retErr := r.handleCPUProfile(r.runnerConfig)
 	if retErr != nil {
@@ -134,54 +239,88 @@ func (r *genericRunner[RunnerConfig, Input, Option, Solution]) Run(
 		}
 	}()
 	// get IO
-	ioData, retErr := r.IOProducer(ctx, r.runnerConfig)
+	ioStart := time.Now()
+	ioData, retErr := ioProducer(ctx, r.runnerConfig)
+	observePhase(ctx, phaseIO, ioStart)
 	if retErr != nil {
 		return retErr
 	}
 
 	if r.InputValidator != nil {
+		validateStart := time.Now()
 		retErr = r.InputValidator(ctx, ioData.Input())
+		observePhase(ctx, phaseValidate, validateStart)
 		if retErr != nil {
 			return retErr
 		}
 	}
 
 	// decode input
+	decodeStart := time.Now()
 	decodedInput, retErr := r.InputDecoder(ctx, ioData.Input())
 	if retErr != nil {
-		return retErr
+		return decodeError(retErr)
 	}
 
-	// use options configured in runner via flags and environment variables
-	decodedOption := r.flagParsedOption
-	// decode option if provided
-	tempOption, err := r.OptionDecoder(ctx, ioData.Option())
-	if err != nil {
-		return err
+	// decode option if provided and merge it field by field into the options
+	// configured via flags and environment variables
+	requestOption, retErr := r.OptionDecoder(ctx, ioData.Option())
+	if retErr != nil {
+		return decodeError(retErr)
 	}
-	var defaultOption Option
-	// if option is not default, use it
-	if !reflect.DeepEqual(tempOption, defaultOption) {
-		decodedOption = tempOption
+	decodedOption, optionSources := mergeOption(
+		r.flagParsedOption, r.optionSources, requestOption, SourceRequest,
+		requestOptions[Option](ioData.Option()),
+	)
+	ctx = context.WithValue(ctx, Sources, optionSources)
+	observePhase(ctx, phaseDecode, decodeStart)
+
+	// replay the output recorded in the input instead of running the
+	// algorithm, unless it is to be compared with a fresh solution
+	recorded, replay, retErr := recordedOutput(ioData.Input())
+	if retErr != nil {
+		return retErr
+	}
+	replayer, ok := any(r.runnerConfig).(Replayer)
+	diff := replay && ok && replayer.ReplayDiff()
+	if replay && !diff {
+		return r.replay(ctx, recorded, ioData.Writer(), decodedOption)
 	}
 
 	// run algorithm
 	solutions := make(chan Solution)
 	errs := make(chan error, 1)
+	algorithmStart := time.Now()
+	startAlgorithm(ctx, algorithmStart)
 	go func() {
 		defer close(solutions)
 		defer close(errs)
-		retErr = r.Algorithm(ctx, decodedInput, decodedOption, solutions)
-		if retErr != nil {
-			errs <- retErr
+		err := r.Algorithm(ctx, decodedInput, decodedOption, solutions)
+		observePhase(ctx, phaseAlgorithm, algorithmStart)
+		if err != nil {
+			errs <- err
 			return
 		}
 	}()
+	forwarded, algorithmErr := forwardSolutions(
+		ctx, solutions, errs, criteria,
+		gracePeriod(r.runnerConfig), onlyLast(r.runnerConfig),
+	)
+	forwarded = observeSolutions(ctx, forwarded)
+	var last <-chan *Solution
+	if diff {
+		forwarded, last = keepLast(forwarded)
+	}
+	// drain solutions the encoder did not consume, e.g. after an error
+	defer func() { go drain(forwarded) }()
 
 	// encode solutions
+	encodeStart := time.Now()
 	retErr = r.Encoder.Encode(
-		ctx, solutions, ioData.Writer(), r.runnerConfig, decodedOption,
+		ctx, forwarded, ioData.Writer(), r.runnerConfig, decodedOption,
 	)
+	observePhase(ctx, phaseEncode, encodeStart)
+	observeTimings(ctx)
 	if retErr != nil {
 		return retErr
 	}
@@ -201,7 +340,199 @@ func (r *genericRunner[RunnerConfig, Input, Option, Solution]) Run(
 	}()
 
 	// return potential errors
-	return <-errs
+	if retErr = <-algorithmErr; retErr != nil {
+		return retErr
+	}
+	if diff {
+		var solution any
+		if lastSolution := <-last; lastSolution != nil {
+			solution = *lastSolution
+		}
+		return compareRecorded(recorded, solution, replayer.ReplayIgnore())
+	}
+	return nil
+}
+
+type solutionObserverKey string
+
+// solutionsKey is the context key of the solutionObserver of a run.
+const solutionsKey solutionObserverKey = "solution_observer"
+
+// solutionObserver is called with every solution of a run before it is
+// encoded.
+type solutionObserver func(any)
+
+// withSolutionObserver returns a context which makes the runner report every
+// solution to observe.
+func withSolutionObserver(
+	ctx context.Context, observe solutionObserver,
+) context.Context {
+	return context.WithValue(ctx, solutionsKey, observe)
+}
+
+// observeSolutions reports the solutions to the solutionObserver of ctx, if
+// there is one, while forwarding them.
+func observeSolutions[Solution any](
+	ctx context.Context, solutions <-chan Solution,
+) <-chan Solution {
+	observe, ok := ctx.Value(solutionsKey).(solutionObserver)
+	if !ok {
+		return solutions
+	}
+	forwarded := make(chan Solution)
+	go func() {
+		defer close(forwarded)
+		for solution := range solutions {
+			observe(solution)
+			forwarded <- solution
+		}
+	}()
+	return forwarded
+}
+
+// limitDuration derives a context that is cancelled with TerminationDuration
+// once the duration limit of the runner configuration has passed since start.
+func limitDuration(
+	ctx context.Context, start time.Time, runnerConfig any,
+) (context.Context, context.CancelFunc) {
+	limiter, ok := runnerConfig.(DurationLimiter)
+	if !ok || limiter.DurationLimit() <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadlineCause(
+		ctx, start.Add(limiter.DurationLimit()), TerminationDuration,
+	)
+}
+
+// forwardSolutions passes the solutions of the algorithm on to the encoder as
+// soon as they arrive. Every solution is checked against the criteria, which
+// may cancel ctx, and the solution which met them is marked with the
+// termination. Once ctx is done, solutions are no longer forwarded as they
+// arrive and the algorithm has the grace period to return. Only the last
+// solution it sends in that time is kept, and forwarded with the termination
+// once it returned or the grace period is over. After that the runner stops
+// waiting for it, so that a runaway algorithm cannot block the run. If the
+// algorithm sent no solution in the grace period and only the last solution
+// is encoded, nothing was encoded yet, so a last solution which was forwarded
+// unmarked is sent once more with the termination. Otherwise every solution
+// is forwarded at most once. The returned error channel yields the error of
+// the algorithm, if any, once the solutions channel is closed.
+func forwardSolutions[Solution any](
+	ctx context.Context,
+	solutions <-chan Solution,
+	errs <-chan error,
+	criteria *criteriaChecker,
+	gracePeriod time.Duration,
+	lastOnly bool,
+) (<-chan Solution, <-chan error) {
+	forwarded := make(chan Solution)
+	algorithmErr := make(chan error, 1)
+	go func() {
+		defer close(algorithmErr)
+		defer close(forwarded)
+
+		var last, held Solution
+		// marked is set if the last solution was forwarded with the
+		// termination, held is the last solution sent after it
+		hasLast, marked, hasHeld := false, false, false
+		terminated := false
+		var termination Termination
+		done := ctx.Done()
+		grace := time.NewTimer(gracePeriod)
+		grace.Stop()
+		defer grace.Stop()
+
+		terminate := func() {
+			if terminated {
+				return
+			}
+			done = nil
+			terminated = true
+			termination = terminationOf(ctx)
+			grace.Reset(gracePeriod)
+		}
+		trailer := func() {
+			switch {
+			case hasHeld:
+				observeSolution(ctx, held)
+				forwarded <- markTermination(held, termination)
+			case lastOnly && terminated && hasLast && !marked:
+				forwarded <- markTermination(last, termination)
+			}
+		}
+
+		for {
+			select {
+			case solution, ok := <-solutions:
+				if !ok {
+					trailer()
+					err := <-errs
+					if terminated && isTermination(err) {
+						err = terminationErr(ctx)
+					}
+					algorithmErr <- err
+					return
+				}
+				if ctx.Err() != nil {
+					// hold the solution back until the algorithm returned
+					terminate()
+					if criteria.accept(solution) {
+						held, hasHeld = solution, true
+					}
+					continue
+				}
+				if !criteria.accept(solution) {
+					continue
+				}
+				observeSolution(ctx, solution)
+				// the criteria cancel ctx right away, the solution which met
+				// them is marked
+				if ctx.Err() != nil {
+					terminate()
+					solution = markTermination(solution, termination)
+				}
+				last, hasLast, marked = solution, true, terminated
+				forwarded <- solution
+			case <-done:
+				terminate()
+			case <-grace.C:
+				// abandon the algorithm but keep it from blocking on send
+				go drain(solutions)
+				trailer()
+				algorithmErr <- terminationErr(ctx)
+				return
+			}
+		}
+	}()
+	return forwarded, algorithmErr
+}
+
+// onlyLast reports whether only the last solution is encoded, according to
+// the runner configuration.
+func onlyLast(runnerConfig any) bool {
+	limiter, ok := runnerConfig.(SolutionLimiter)
+	if !ok {
+		return false
+	}
+	solutions, err := limiter.Solutions()
+	return err == nil && solutions == Last
+}
+
+// drain discards all remaining values of ch.
+func drain[T any](ch <-chan T) {
+	for range ch {
+	}
+}
+
+// terminationErr returns the error of a run whose context is done. Limits
+// enforced by the runner are expected outcomes and therefore not an error.
+func terminationErr(ctx context.Context) error {
+	var termination Termination
+	cause := context.Cause(ctx)
+	if errors.As(cause, &termination) {
+		return nil
+	}
+	return cause
 }
 
 func (r *genericRunner[RunnerConfig, Input, Option, Solution]) SetIOProducer(
@@ -220,6 +551,17 @@ func (r *genericRunner[RunnerConfig, Input, Option, Solution]) SetInputValidator
 	validator Validator[Input],
 ) {
 	r.InputValidator = validator
+	r.defaultValidator = false
+}
+
+func (r *genericRunner[RunnerConfig, Input, Option, Solution]) setFormatDecoder(
+	decoder Decoder[Input],
+) {
+	r.InputDecoder = decoder
+	if r.defaultValidator {
+		r.InputValidator = nil
+		r.defaultValidator = false
+	}
 }
 
 func (r *genericRunner[RunnerConfig, Input, Option, Solution]) SetOptionDecoder(
@@ -246,6 +588,21 @@ func (r *genericRunner[
 	return r.Encoder
 }
 
+func (r *genericRunner[RunnerConfig, Input, Option, Solution]) SetConfig(
+	runnerConfig RunnerConfig, option Option,
+) {
+	r.runnerConfig = runnerConfig
+	r.flagParsedOption = option
+	r.optionSources = OptionSources{}
+	optionFields(
+		reflect.ValueOf(option), "",
+		func(name string, _ reflect.Value) {
+			r.optionSources[name] = SourceConfig
+		},
+	)
+	r.configured = true
+}
+
 func (r *genericRunner[
 	RunnerConfig, Input, Option, Solution],
 ) RunnerConfig() RunnerConfig {
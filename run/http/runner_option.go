@@ -7,7 +7,8 @@ import (
 	"github.com/nextmv-io/sdk/run/encode"
 )
 
-// Decode sets the decoder of a HTTPRunner.
+// Decode sets the decoder of a HTTPRunner. It is used for all requests,
+// regardless of their Content-Type.
 func Decode[Input, Option, Solution any, Decoder decode.Decoder](
 	d Decoder,
 ) func(
@@ -18,7 +19,8 @@ func Decode[Input, Option, Solution any, Decoder decode.Decoder](
 	)
 }
 
-// Encode sets the encoder of a HTTPRunner.
+// Encode sets the encoder of a HTTPRunner. It is used for all requests,
+// regardless of their Accept header.
 func Encode[Input, Option, Solution any, Encoder encode.Encoder](
 	e Encoder,
 ) func(
@@ -0,0 +1,331 @@
+package run
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nextmv-io/sdk/run/decode"
+	"github.com/nextmv-io/sdk/run/encode"
+	"github.com/nextmv-io/sdk/run/validate"
+)
+
+// RegisterDecoder registers a decoder for inputs of the given content type,
+// e.g. application/msgpack, in addition to JSON, YAML, TOML, XML, Gob and CSV,
+// which may be sent as a single file or as a zip archive of files. It has no
+// effect if the input decoder is replaced by a runner option.
+func RegisterDecoder[Input, Option, Solution any](
+	contentType string, decoder decode.Decoder,
+) func(*httpRunner[Input, Option, Solution]) {
+	return func(r *httpRunner[Input, Option, Solution]) {
+		r.codecs.decoders[normalizeMediaType(contentType)] = decoder
+	}
+}
+
+// RegisterEncoder registers an encoder for outputs of the given content type,
+// in addition to JSON, YAML, TOML, XML, Gob and CSV. It has no effect if the encoder is
+// replaced by a runner option or if streaming is configured.
+func RegisterEncoder[Input, Option, Solution any](
+	contentType string, encoder encode.Encoder,
+) func(*httpRunner[Input, Option, Solution]) {
+	return func(r *httpRunner[Input, Option, Solution]) {
+		contentType = normalizeMediaType(contentType)
+		if _, ok := r.codecs.encoders[contentType]; !ok {
+			r.codecs.encoderTypes = append(r.codecs.encoderTypes, contentType)
+		}
+		r.codecs.encoders[contentType] = encoder
+	}
+}
+
+var (
+	// errUnsupportedMediaType is returned if there is no decoder for the
+	// content type or no support for the content encoding of a request.
+	errUnsupportedMediaType = errors.New("unsupported media type")
+	// errNotAcceptable is returned if there is no encoder for any of the
+	// content types a request accepts.
+	errNotAcceptable = errors.New("none of the accepted content types is supported")
+)
+
+// codecs holds the decoders and encoders by content type.
+type codecs struct {
+	decoders map[string]decode.Decoder
+	encoders map[string]encode.Encoder
+	// encoderTypes are the content types of the encoders in the order of
+	// their registration, the first one is used if a client accepts anything
+	encoderTypes []string
+}
+
+func newCodecs() codecs {
+	return codecs{
+		decoders: map[string]decode.Decoder{
+			"application/json":   decode.JSON(),
+			"application/yaml":   decode.YAML(),
+			"application/x-yaml": decode.YAML(),
+			"text/yaml":          decode.YAML(),
+			"application/toml":   decode.TOML(),
+			"application/xml":    decode.XML(),
+			"text/xml":           decode.XML(),
+			"application/gob":    decode.Gob(),
+			"text/csv":           decode.CSV(),
+			// an archive of CSV files
+			"application/zip": decode.CSV(),
+		},
+		encoders: map[string]encode.Encoder{
+			"application/json": encode.JSON(),
+			"application/yaml": encode.YAML(),
+			"application/toml": encode.TOML(),
+			"application/xml":  encode.XML(),
+			"application/gob":  encode.Gob(),
+			"text/csv":         encode.CSV(),
+		},
+		encoderTypes: []string{
+			"application/json", "application/yaml", "application/toml",
+			"application/xml", "application/gob", "text/csv",
+		},
+	}
+}
+
+// negotiation is the outcome of the content negotiation of a request. It is
+// passed to the negotiating decoder and encoder with the context of the run.
+type negotiation struct {
+	// contentType of the input, application/json if the request has none
+	contentType string
+	// decoder is nil if there is no decoder for the content type
+	decoder     decode.Decoder
+	encoder     encode.Encoder
+	encoderType string
+}
+
+type negotiationKey string
+
+// negotiated is the context key of the negotiation of a run.
+const negotiated negotiationKey = "negotiation"
+
+// negotiateDecoder selects the decoder for the content type of the request.
+// Requests without a content type are assumed to be JSON. Compressed requests
+// are decompressed by NewIOData, so only gzip is supported as content
+// encoding.
+func (c codecs) negotiateDecoder(req *http.Request) (negotiation, error) {
+	encoding := strings.TrimSpace(req.Header.Get("Content-Encoding"))
+	if encoding != "" && !strings.EqualFold(encoding, "gzip") &&
+		!strings.EqualFold(encoding, "identity") {
+		return negotiation{}, errUnsupportedMediaType
+	}
+	contentType := "application/json"
+	if header := req.Header.Get("Content-Type"); header != "" {
+		contentType = normalizeMediaType(header)
+	}
+	return negotiation{
+		contentType: contentType,
+		decoder:     c.decoders[contentType],
+	}, nil
+}
+
+// negotiateEncoder selects the encoder for the most preferred content type the
+// request accepts. Requests without an Accept header get the first encoder.
+func (c codecs) negotiateEncoder(req *http.Request, n *negotiation) error {
+	header := req.Header.Get("Accept")
+	if header == "" {
+		header = "*/*"
+	}
+	for _, accepted := range parseAccept(header) {
+		for _, contentType := range c.encoderTypes {
+			if matchesMediaRange(contentType, accepted.value) {
+				n.encoder = c.encoders[contentType]
+				n.encoderType = contentType
+				return nil
+			}
+		}
+	}
+	return errNotAcceptable
+}
+
+// acceptedValue is a value of an Accept or Accept-Encoding header with its
+// quality.
+type acceptedValue struct {
+	value   string
+	quality float64
+}
+
+// parseAccept returns the acceptable values of an Accept or Accept-Encoding
+// header, the most preferred ones first. Values with a quality of 0 are not
+// acceptable and left out.
+func parseAccept(header string) []acceptedValue {
+	var values []acceptedValue
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, q, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		if quality > 0 {
+			values = append(values, acceptedValue{value, quality})
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].quality > values[j].quality
+	})
+	return values
+}
+
+// matchesMediaRange reports whether contentType is in the media range of an
+// Accept header, e.g. application/* or */*.
+func matchesMediaRange(contentType, mediaRange string) bool {
+	if mediaRange == "*/*" || mediaRange == contentType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(contentType, prefix+"/")
+}
+
+// acceptsGzip reports whether a response to the request may be compressed
+// with gzip.
+func acceptsGzip(req *http.Request) bool {
+	for _, accepted := range parseAccept(req.Header.Get("Accept-Encoding")) {
+		if accepted.value == "gzip" || accepted.value == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeMediaType returns the lower case media type of a Content-Type
+// header without its parameters.
+func normalizeMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mediaType
+}
+
+// isJSON reports whether the media type is JSON, e.g. application/json or
+// application/geo+json.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json")
+}
+
+// negotiatingDecoder decodes the input with the decoder negotiated for the
+// run. Runs without a negotiation decode JSON.
+func negotiatingDecoder[Input any](
+	ctx context.Context, reader any,
+) (Input, error) {
+	n, ok := ctx.Value(negotiated).(negotiation)
+	if !ok {
+		return GenericDecoder[Input](decode.JSON())(ctx, reader)
+	}
+	if n.decoder == nil {
+		var input Input
+		return input, errUnsupportedMediaType
+	}
+	return GenericDecoder[Input](n.decoder)(ctx, reader)
+}
+
+// jsonValidator validates JSON inputs against the schema of the Input type.
+// Inputs whose decoder is a decode.JSONConverter, such as YAML and TOML, are
+// converted to JSON and validated as well. Inputs in other formats are not
+// validated, as the schema describes JSON. Directories are rejected, as none of
+// the negotiated decoders reads them.
+func jsonValidator[Input any]() Validator[Input] {
+	validator := validate.JSON[Input](nil)
+	return func(ctx context.Context, input any) error {
+		if _, ok := input.(fs.FS); ok {
+			return DecodeError{Err: errDirectory}
+		}
+		n, ok := ctx.Value(negotiated).(negotiation)
+		if !ok || isJSON(n.contentType) {
+			return validator(ctx, input)
+		}
+		converter, ok := n.decoder.(decode.JSONConverter)
+		if !ok {
+			return nil
+		}
+		reader, ok := input.(io.Reader)
+		if !ok {
+			return nil
+		}
+		data, err := converter.ToJSON(reader)
+		if err != nil {
+			return DecodeError{Err: err}
+		}
+		return validator(ctx, bytes.NewReader(data))
+	}
+}
+
+// negotiatingEncoder encodes the solutions with the encoder negotiated for the
+// run. Runs without a negotiation encode JSON.
+type negotiatingEncoder[Solution, Option any] struct{}
+
+func (e *negotiatingEncoder[Solution, Option]) Encode(
+	ctx context.Context,
+	solutions <-chan Solution,
+	writer any,
+	runnerConfig any,
+	option Option,
+) error {
+	var encoder encode.Encoder = encode.JSON()
+	if n, ok := ctx.Value(negotiated).(negotiation); ok {
+		encoder = n.encoder
+	}
+	return GenericEncoder[Solution, Option](encoder).Encode(
+		ctx, solutions, writer, runnerConfig, option,
+	)
+}
+
+// ContentType is the content type of runs without a negotiation.
+func (e *negotiatingEncoder[Solution, Option]) ContentType() string {
+	return "application/json"
+}
+
+// gzipResponseWriter compresses the response with gzip. It must be closed to
+// write the end of the compressed stream.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gzip *gzip.Writer
+}
+
+func newGzipResponseWriter(w http.ResponseWriter) *gzipResponseWriter {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	return &gzipResponseWriter{ResponseWriter: w, gzip: gzip.NewWriter(w)}
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	return w.gzip.Write(b)
+}
+
+// Flush sends the data compressed so far to the client, so that streamed
+// solutions arrive right away.
+func (w *gzipResponseWriter) Flush() {
+	if err := w.gzip.Flush(); err != nil {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// close writes the end of the compressed stream. It is not Close, so that
+// encoders, which close their writer, do not end the response before an error
+// is written to it.
+func (w *gzipResponseWriter) close() error {
+	return w.gzip.Close()
+}
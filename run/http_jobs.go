@@ -0,0 +1,228 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// startJob saves the async run with the given id as queued. It returns the
+// context of the run, which is tied to the lifetime of the runner, a producer
+// which captures the output of the run and a function which records and
+// returns the outcome of the run once it returned.
+func (h *httpRunner[Input, Option, Solution]) startJob(
+	id string, contentType string, producer IOProducer[HTTPRunnerConfig],
+) (context.Context, IOProducer[HTTPRunnerConfig], func(error) Job) {
+	ctx, cancel := context.WithCancelCause(h.lifetime)
+	h.cancelJobs.Store(id, cancel)
+
+	now := time.Now()
+	job := Job{
+		ID:          id,
+		Status:      JobQueued,
+		ContentType: contentType,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	h.saveJob(job)
+
+	output := &bytes.Buffer{}
+	teeProducer := func(
+		ctx context.Context, runnerConfig HTTPRunnerConfig,
+	) (IOData, error) {
+		data, err := producer(ctx, runnerConfig)
+		if err != nil {
+			return nil, err
+		}
+		job.Status = JobRunning
+		h.saveJob(job)
+		return teeIOData{IOData: data, output: output}, nil
+	}
+
+	finish := func(err error) Job {
+		h.cancelJobs.Delete(id)
+		canceled := errors.Is(context.Cause(ctx), TerminationCanceled)
+		cancel(nil)
+		if err == nil {
+			// store the output before the status, so that the output of a
+			// finished run is always available
+			err = h.jobStore.SaveResult(context.Background(), id, output.Bytes())
+		}
+		switch {
+		case canceled && (err == nil || job.Status == JobQueued):
+			// runs cancelled while queued have no output
+			job.Status = JobCanceled
+		case err != nil:
+			job.Status = JobFailed
+			job.Error = err.Error()
+		default:
+			job.Status = JobSucceeded
+		}
+		h.saveJob(job)
+		return job
+	}
+	return ctx, teeProducer, finish
+}
+
+// runContext returns the context of a sync run. It is cancelled when the
+// request context is done, e.g. because the client disconnected, or when the
+// runner stopped and the run did not finish within the grace period.
+func (h *httpRunner[Input, Option, Solution]) runContext(
+	parent context.Context,
+) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	stop := context.AfterFunc(h.lifetime, func() {
+		cancel(context.Cause(h.lifetime))
+	})
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// saveJob saves the job and logs errors, as they cannot be reported to the
+// client of an async run.
+func (h *httpRunner[Input, Option, Solution]) saveJob(job Job) {
+	job.UpdatedAt = time.Now()
+	if err := h.jobStore.Save(context.Background(), job); err != nil {
+		h.httpServer.ErrorLog.Println(err)
+	}
+}
+
+// serveJob serves the async runs below /runs/:
+//
+//   - GET /runs/{id} returns the Job as JSON.
+//   - GET /runs/{id}/result returns the encoded output of a succeeded or
+//     cancelled run.
+//   - DELETE /runs/{id} cancels a queued or running run, which then stores
+//     the output found so far, or removes a finished run.
+func (h *httpRunner[Input, Option, Solution]) serveJob(
+	w http.ResponseWriter, req *http.Request,
+) {
+	id, resource, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/runs/"), "/")
+	switch {
+	case resource == "" && req.Method == http.MethodGet:
+		h.getJob(w, req, id)
+	case resource == "" && req.Method == http.MethodDelete:
+		h.deleteJob(w, req, id)
+	case resource == "result" && req.Method == http.MethodGet:
+		h.getJobResult(w, req, id)
+	case resource == "":
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	case resource == "result":
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (h *httpRunner[Input, Option, Solution]) getJob(
+	w http.ResponseWriter, req *http.Request, id string,
+) {
+	job, err := h.jobStore.Job(req.Context(), id)
+	if err != nil {
+		h.handleJobError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(job); err != nil {
+		h.httpServer.ErrorLog.Println(err)
+	}
+}
+
+func (h *httpRunner[Input, Option, Solution]) getJobResult(
+	w http.ResponseWriter, req *http.Request, id string,
+) {
+	job, err := h.jobStore.Job(req.Context(), id)
+	if err != nil {
+		h.handleJobError(w, err)
+		return
+	}
+	result, err := h.jobStore.Result(req.Context(), id)
+	// runs cancelled while queued have no output
+	if job.Status != JobSucceeded && job.Status != JobCanceled ||
+		errors.Is(err, ErrJobNotFound) && job.Status == JobCanceled {
+		http.Error(
+			w, fmt.Sprintf("run %s is %s", id, job.Status), http.StatusConflict,
+		)
+		return
+	}
+	if err != nil {
+		h.handleJobError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", job.ContentType)
+	if _, err := w.Write(result); err != nil {
+		h.httpServer.ErrorLog.Println(err)
+	}
+}
+
+func (h *httpRunner[Input, Option, Solution]) deleteJob(
+	w http.ResponseWriter, req *http.Request, id string,
+) {
+	if cancel, ok := h.cancelJobs.Load(id); ok {
+		cancel.(context.CancelCauseFunc)(TerminationCanceled)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	if _, err := h.jobStore.Job(req.Context(), id); err != nil {
+		h.handleJobError(w, err)
+		return
+	}
+	if err := h.jobStore.Delete(req.Context(), id); err != nil {
+		h.handleJobError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleJobError responds with not found for unknown jobs and with an internal
+// server error otherwise.
+func (h *httpRunner[Input, Option, Solution]) handleJobError(
+	w http.ResponseWriter, err error,
+) {
+	if errors.Is(err, ErrJobNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	h.httpServer.ErrorLog.Println(err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// teeIOData copies everything that is written to the writer of the IOData to
+// output.
+type teeIOData struct {
+	IOData
+	output io.Writer
+}
+
+func (d teeIOData) Writer() any {
+	writer, ok := d.IOData.Writer().(io.Writer)
+	if !ok {
+		return d.IOData.Writer()
+	}
+	closer, _ := writer.(io.Closer)
+	return teeWriter{Writer: io.MultiWriter(writer, d.output), closer: closer}
+}
+
+// teeWriter is an io.WriteCloser which closes the original writer, if it is an
+// io.Closer.
+type teeWriter struct {
+	io.Writer
+	closer io.Closer
+}
+
+func (w teeWriter) Close() error {
+	if w.closer == nil {
+		return nil
+	}
+	return w.closer.Close()
+}
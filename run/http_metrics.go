@@ -0,0 +1,203 @@
+package run
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds in seconds of the buckets of the phase
+// duration histograms. They reach further than usual for http servers, as
+// algorithms commonly run for minutes.
+var durationBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300,
+}
+
+// histogram counts observations in cumulative buckets like a Prometheus
+// histogram.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(durationBuckets))
+	}
+	for i, bound := range durationBuckets {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+// metrics collects the request counts and the phase durations of the runs of
+// the HTTPRunner.
+type metrics struct {
+	mutex     sync.Mutex
+	requests  map[int]uint64
+	durations map[phase]*histogram
+}
+
+func newMetrics() *metrics {
+	durations := make(map[phase]*histogram, len(phases))
+	for _, p := range phases {
+		durations[p] = &histogram{}
+	}
+	return &metrics{requests: map[int]uint64{}, durations: durations}
+}
+
+// observe is the phaseObserver of all runs.
+func (m *metrics) observe(p phase, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.durations[p].observe(duration.Seconds())
+}
+
+// countRequest counts a run request which was answered with status.
+func (m *metrics) countRequest(status int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.requests[status]++
+}
+
+// write writes the metrics together with the given gauges in the Prometheus
+// text exposition format.
+func (m *metrics) write(w *bufio.Writer, activeRuns, queueDepth int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	writeHeader(w, "nextmv_runner_active_runs", "gauge",
+		"Number of runs which are currently active.")
+	fmt.Fprintf(w, "nextmv_runner_active_runs %d\n", activeRuns)
+
+	writeHeader(w, "nextmv_runner_queue_depth", "gauge",
+		"Number of requests waiting for a free slot.")
+	fmt.Fprintf(w, "nextmv_runner_queue_depth %d\n", queueDepth)
+
+	writeHeader(w, "nextmv_runner_requests_total", "counter",
+		"Number of run requests by response status code.")
+	statuses := make([]int, 0, len(m.requests))
+	for status := range m.requests {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "nextmv_runner_requests_total{code=\"%d\"} %d\n",
+			status, m.requests[status])
+	}
+
+	name := "nextmv_runner_phase_duration_seconds"
+	writeHeader(w, name, "histogram",
+		"Duration of the io, validate, decode, algorithm and encode phases of runs.")
+	for _, p := range phases {
+		h := m.durations[p]
+		for i, bound := range durationBuckets {
+			var count uint64
+			if h.counts != nil {
+				count = h.counts[i]
+			}
+			fmt.Fprintf(w, "%s_bucket{phase=%q,le=%q} %d\n",
+				name, p, strconv.FormatFloat(bound, 'g', -1, 64), count)
+		}
+		fmt.Fprintf(w, "%s_bucket{phase=%q,le=\"+Inf\"} %d\n", name, p, h.count)
+		fmt.Fprintf(w, "%s_sum{phase=%q} %s\n",
+			name, p, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count{phase=%q} %d\n", name, p, h.count)
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// statusRecorder remembers the status code of a response. It implements
+// http.Flusher, so that streamed solutions are still sent right away.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter for the
+// http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// countRequests counts the requests served by next by their status code.
+// Requests without a response, e.g. because the client is gone, are not
+// counted.
+func (h *httpRunner[Input, Option, Solution]) countRequests(
+	next http.HandlerFunc,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+		next(recorder, req)
+		if recorder.status != 0 {
+			h.metrics.countRequest(recorder.status)
+		}
+	}
+}
+
+// serveHealth reports that the server is alive.
+func (h *httpRunner[Input, Option, Solution]) serveHealth(
+	w http.ResponseWriter, _ *http.Request,
+) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// serveReady reports whether the server accepts new runs. It is not ready
+// while it shuts down or while all slots are busy and the queue is full, so
+// that a load balancer sends requests elsewhere.
+func (h *httpRunner[Input, Option, Solution]) serveReady(
+	w http.ResponseWriter, _ *http.Request,
+) {
+	switch {
+	case h.shuttingDown.Load():
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+	case h.queue.saturated():
+		http.Error(w, "saturated", http.StatusServiceUnavailable)
+	default:
+		h.serveHealth(w, nil)
+	}
+}
+
+// serveMetrics serves the metrics in the Prometheus text exposition format.
+func (h *httpRunner[Input, Option, Solution]) serveMetrics(
+	w http.ResponseWriter, _ *http.Request,
+) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	buffered := bufio.NewWriter(w)
+	h.metrics.write(buffered, h.ActiveRuns(), h.QueueDepth())
+	if err := buffered.Flush(); err != nil {
+		h.httpServer.ErrorLog.Println(err)
+	}
+}
@@ -0,0 +1,475 @@
+package run
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	humaSchema "github.com/danielgtaylor/huma/schema"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+// openAPIDocument is an OpenAPI 3.0 document. Only the parts used to describe
+// the HTTPRunner are modelled.
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       openAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components openAPIComponents                      `json:"components"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*humaSchema.Schema `json:"schemas"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOperation struct {
+	Summary     string                                            `json:"summary"`
+	OperationID string                                            `json:"operationId,omitempty"` //nolint:tagliatelle
+	Parameters  []openAPIParameter                                `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody                               `json:"requestBody,omitempty"` //nolint:tagliatelle
+	Responses   map[string]openAPIResponse                        `json:"responses"`
+	Callbacks   map[string]map[string]map[string]openAPIOperation `json:"callbacks,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Schema      *humaSchema.Schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *humaSchema.Schema `json:"schema"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Headers     map[string]openAPIHeader    `json:"headers,omitempty"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIHeader struct {
+	Description string             `json:"description"`
+	Schema      *humaSchema.Schema `json:"schema"`
+}
+
+var (
+	stringSchema = &humaSchema.Schema{Type: humaSchema.TypeString}
+	textContent  = map[string]openAPIMediaType{"text/plain": {Schema: stringSchema}}
+	retryAfter   = map[string]openAPIHeader{"Retry-After": {
+		Description: "Seconds after which a request is likely to get a slot.",
+		Schema:      &humaSchema.Schema{Type: humaSchema.TypeInteger},
+	}}
+)
+
+// serveOpenAPI serves the OpenAPI document of the runner. It is generated on
+// the first request, as it depends on the configured encoder.
+func (h *httpRunner[Input, Option, Solution]) serveOpenAPI(
+	w http.ResponseWriter, _ *http.Request,
+) {
+	h.openAPI.once.Do(func() {
+		h.openAPI.document, h.openAPI.err = h.generateOpenAPI()
+	})
+	if h.openAPI.err != nil {
+		h.httpServer.ErrorLog.Println(h.openAPI.err)
+		http.Error(w, h.openAPI.err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(h.openAPI.document); err != nil {
+		h.httpServer.ErrorLog.Println(err)
+	}
+}
+
+// openAPI caches the generated OpenAPI document.
+type openAPI struct {
+	once     sync.Once
+	document []byte
+	err      error
+}
+
+// generateOpenAPI describes the runs of the runner: the input as request
+// body, the options as query parameters, the solution as response and the
+// callback of async runs, as well as the async runs below /runs/.
+func (h *httpRunner[Input, Option, Solution]) generateOpenAPI() ([]byte, error) {
+	input, err := humaSchema.Generate(reflect.TypeOf(new(Input)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	solution, err := solutionSchema[Option, Solution]()
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := queryParameters(reflect.TypeOf(new(Option)).Elem(), "")
+	if err != nil {
+		return nil, err
+	}
+	violations, err := humaSchema.Generate(reflect.TypeOf(validationErrorBody{}))
+	if err != nil {
+		return nil, err
+	}
+	job, err := humaSchema.Generate(reflect.TypeOf(Job{}))
+	if err != nil {
+		return nil, err
+	}
+
+	// the output is encoded in any of the registered content types, unless
+	// the encoder is replaced
+	contentTypes := h.codecs.encoderTypes
+	if h.Runner.GetEncoder() != h.defaultEncoder {
+		contentTypes = []string{"application/json"}
+		if contentTyper, ok := h.Runner.GetEncoder().(ContentTyper); ok {
+			contentTypes = []string{contentTyper.ContentType()}
+		}
+	}
+	schemas := map[string]*humaSchema.Schema{
+		"Input":           input,
+		"Solution":        solution,
+		"ValidationError": violations,
+		"Job":             job,
+	}
+	solutionContent := map[string]openAPIMediaType{}
+	for _, contentType := range contentTypes {
+		solutionContent[contentType] = openAPIMediaType{Schema: ref("Solution")}
+	}
+	inputContent := map[string]openAPIMediaType{}
+	for contentType := range h.codecs.decoders {
+		inputContent[contentType] = openAPIMediaType{Schema: ref("Input")}
+	}
+	// the id of an async run is returned as text
+	runContent := map[string]openAPIMediaType{"text/plain": {Schema: stringSchema}}
+	for contentType, mediaType := range solutionContent {
+		runContent[contentType] = mediaType
+	}
+
+	parameters = append(parameters, openAPIParameter{
+		Name: "callback_url",
+		In:   "header",
+		Description: "The url the output of an async run is posted to. " +
+			"Only used if the runner handles requests asynchronously.",
+		Schema: &humaSchema.Schema{Type: humaSchema.TypeString, Format: "uri"},
+	})
+	callback := openAPIOperation{
+		Summary: "Receive the output of an async run",
+		Parameters: []openAPIParameter{
+			{
+				Name:        "request_id",
+				In:          "header",
+				Description: "The id of the run.",
+				Required:    true,
+				Schema:      stringSchema,
+			},
+			{
+				Name: "status",
+				In:   "header",
+				Description: "The status of the run, succeeded or failed. The " +
+					"body of a failed run is a JSON object with its error.",
+				Required: true,
+				Schema:   stringSchema,
+			},
+			{
+				Name: "signature",
+				In:   "header",
+				Description: "sha256=<hex>, the HMAC-SHA256 of the request id, " +
+					"a dot and the body, if a callback secret is set.",
+				Schema: stringSchema,
+			},
+		},
+		RequestBody: &openAPIRequestBody{Required: true, Content: solutionContent},
+		Responses: map[string]openAPIResponse{
+			"2XX": {Description: "The output was received."},
+		},
+	}
+	runOperation := openAPIOperation{
+		Summary:     "Run the algorithm",
+		OperationID: "run",
+		Parameters:  parameters,
+		RequestBody: &openAPIRequestBody{Required: true, Content: inputContent},
+		Responses: map[string]openAPIResponse{
+			"200": {
+				Description: "The output of a sync run or, as text, the id " +
+					"of an async run.",
+				Content: runContent,
+			},
+			"400": {
+				Description: "The input is invalid.",
+				Content: map[string]openAPIMediaType{
+					"application/json": {Schema: ref("ValidationError")},
+				},
+			},
+			"406": {
+				Description: "None of the accepted content types is supported.",
+				Content:     textContent,
+			},
+			"415": {
+				Description: "The content type or encoding of the input is " +
+					"not supported.",
+				Content: textContent,
+			},
+			"429": {
+				Description: "All slots are busy and the queue is full.",
+				Headers:     retryAfter,
+				Content:     textContent,
+			},
+			"500": {Description: "The run failed.", Content: textContent},
+			"503": {
+				Description: "No slot became free within the queue timeout.",
+				Headers:     retryAfter,
+				Content:     textContent,
+			},
+		},
+		Callbacks: map[string]map[string]map[string]openAPIOperation{
+			"output": {
+				"{$request.header.callback_url}": {"post": callback},
+			},
+		},
+	}
+
+	id := openAPIParameter{
+		Name:     "id",
+		In:       "path",
+		Required: true,
+		Schema:   stringSchema,
+	}
+	notFound := openAPIResponse{
+		Description: "The run is unknown or expired.",
+		Content:     textContent,
+	}
+	document := openAPIDocument{
+		OpenAPI:    "3.0.3",
+		Info:       documentInfo(),
+		Components: openAPIComponents{Schemas: schemas},
+		Paths: map[string]map[string]openAPIOperation{
+			"/": {"post": runOperation},
+			"/runs/{id}": {
+				"get": {
+					Summary:     "Get the status of an async run",
+					OperationID: "getRun",
+					Parameters:  []openAPIParameter{id},
+					Responses: map[string]openAPIResponse{
+						"200": {
+							Description: "The status of the run.",
+							Content: map[string]openAPIMediaType{
+								"application/json": {Schema: ref("Job")},
+							},
+						},
+						"404": notFound,
+					},
+				},
+				"delete": {
+					Summary:     "Cancel an active async run or remove a finished one",
+					OperationID: "deleteRun",
+					Parameters:  []openAPIParameter{id},
+					Responses: map[string]openAPIResponse{
+						"202": {Description: "The run is cancelled."},
+						"204": {Description: "The run is removed."},
+						"404": notFound,
+					},
+				},
+			},
+			"/runs/{id}/result": {
+				"get": {
+					Summary:     "Get the output of a succeeded async run",
+					OperationID: "getRunResult",
+					Parameters:  []openAPIParameter{id},
+					Responses: map[string]openAPIResponse{
+						"200": {
+							Description: "The output of the run.",
+							Content:     solutionContent,
+						},
+						"404": notFound,
+						"409": {
+							Description: "The run did not succeed (yet).",
+							Content:     textContent,
+						},
+					},
+				},
+			},
+		},
+	}
+	return json.Marshal(document)
+}
+
+// ref refers to the schema with the given name in the components.
+func ref(name string) *humaSchema.Schema {
+	return &humaSchema.Schema{Ref: "#/components/schemas/" + name}
+}
+
+// documentInfo names the document after the main module of the program.
+func documentInfo() openAPIInfo {
+	info := openAPIInfo{Title: "HTTPRunner", Version: "0.0.0"}
+	if buildInfo, ok := debug.ReadBuildInfo(); ok && buildInfo.Main.Path != "" {
+		info.Title = buildInfo.Main.Path
+		info.Version = buildInfo.Main.Version
+	}
+	return info
+}
+
+// solutionSchema generates the schema of the Solution type. If the solution
+// is a schema.Output, its options are described by the Option type.
+func solutionSchema[Option, Solution any]() (*humaSchema.Schema, error) {
+	solutionType := reflect.TypeOf(new(Solution)).Elem()
+	solution, err := humaSchema.Generate(solutionType)
+	if err != nil {
+		return nil, err
+	}
+	if solutionType != reflect.TypeOf(schema.Output{}) {
+		return solution, nil
+	}
+	option, err := optionSchema(reflect.TypeOf(new(Option)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	solution.Properties["options"] = option
+	return solution, nil
+}
+
+// queryParameters describes the fields of the option type t as query
+// parameters, named like the QueryParamDecoder expects them. Nested fields
+// are joined with dots, like flags. The usage tag describes a parameter and the
+// default tag is its default.
+func queryParameters(t reflect.Type, prefix string) ([]openAPIParameter, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var parameters []openAPIParameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.ToLower(field.Name)
+		if tag := strings.Split(field.Tag.Get("schema"), ",")[0]; tag != "" {
+			name = tag
+		}
+		if name == "-" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if isNested(field.Type) || isNestedPointer(field.Type) {
+			nested, err := queryParameters(field.Type, name)
+			if err != nil {
+				return nil, err
+			}
+			parameters = append(parameters, nested...)
+			continue
+		}
+		s, err := fieldSchema(field)
+		if err != nil {
+			return nil, err
+		}
+		// the parameter carries the description
+		description := s.Description
+		s.Description = ""
+		parameters = append(parameters, openAPIParameter{
+			Name:        name,
+			In:          "query",
+			Description: description,
+			Schema:      s,
+		})
+	}
+	return parameters, nil
+}
+
+// optionSchema describes the option type t as it is encoded to JSON.
+func optionSchema(t reflect.Type) (*humaSchema.Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if !isNested(t) {
+		return humaSchema.Generate(t)
+	}
+	properties := map[string]*humaSchema.Schema{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		var s *humaSchema.Schema
+		var err error
+		if isNested(field.Type) || isNestedPointer(field.Type) {
+			s, err = optionSchema(field.Type)
+		} else {
+			s, err = fieldSchema(field)
+		}
+		if err != nil {
+			return nil, err
+		}
+		properties[name] = s
+	}
+	return &humaSchema.Schema{
+		Type:       humaSchema.TypeObject,
+		Properties: properties,
+	}, nil
+}
+
+// fieldSchema describes an option field. Unlike the schema generator, it reads
+// the default tag like flags do and the usage tag as description.
+func fieldSchema(field reflect.StructField) (*humaSchema.Schema, error) {
+	s, err := humaSchema.Generate(field.Type)
+	if err != nil {
+		return nil, err
+	}
+	if value, ok := field.Tag.Lookup("default"); ok {
+		s.Default = defaultValue(field.Type, value)
+	}
+	s.Description = field.Tag.Get("usage")
+	return s, nil
+}
+
+// defaultValue converts the default tag of a field of type t to the value the
+// query parameter takes. Durations are given in nanoseconds and slices as
+// comma separated values, like flags. Values which cannot be converted are
+// kept as they are.
+func defaultValue(t reflect.Type, value string) any {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration.Nanoseconds()
+		}
+		return value
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		values := []any{}
+		for _, v := range strings.Split(value, ",") {
+			values = append(values, defaultValue(t.Elem(), v))
+		}
+		return values
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
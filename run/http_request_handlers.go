@@ -3,9 +3,16 @@ package run
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"time"
 )
 
 // SyncHTTPRequestHandler allows the input and option to be sent as body and
@@ -38,14 +45,62 @@ func RequestOverride(allow bool) AsyncHTTPRequestHandlerOption {
 	return func(h *asyncHTTPHandler) { h.requestOverride = allow }
 }
 
+// CallbackRetries sets how often a failed callback is retried. Callbacks fail
+// if the request fails, times out or is not answered with a 2xx status. Client
+// errors other than 408 and 429 are not retried. The default is 3.
+func CallbackRetries(retries int) AsyncHTTPRequestHandlerOption {
+	return func(h *asyncHTTPHandler) { h.retries = retries }
+}
+
+// CallbackBackoff sets the delay before the first retry of a callback, which
+// doubles with every further retry up to maxDelay. Every delay is randomized
+// between half and all of its value, so that retries of many runs do not
+// arrive at the same time. The defaults are 500ms and 30s.
+func CallbackBackoff(delay, maxDelay time.Duration) AsyncHTTPRequestHandlerOption {
+	return func(h *asyncHTTPHandler) {
+		h.backoff = delay
+		h.maxBackoff = maxDelay
+	}
+}
+
+// CallbackTimeout sets the timeout of every attempt to send a callback. The
+// default is 30s.
+func CallbackTimeout(timeout time.Duration) AsyncHTTPRequestHandlerOption {
+	return func(h *asyncHTTPHandler) { h.timeout = timeout }
+}
+
+// CallbackSecret sets the secret used to sign callbacks. Signed callbacks carry
+// the header signature: sha256=<hex>, the hex encoded HMAC-SHA256 of the
+// request id, a dot and the body, e.g. "<request_id>.<body>". Receivers verify
+// it to ensure that the result comes from the runner.
+func CallbackSecret(secret []byte) AsyncHTTPRequestHandlerOption {
+	return func(h *asyncHTTPHandler) { h.secret = secret }
+}
+
+// DeadLetterHook is called with the result of an async run whose callback
+// failed permanently, i.e. after all retries or because the runner stopped.
+type DeadLetterHook func(requestID, contentType string, body []byte, err error)
+
+// DeadLetter sets the hook which is called for callbacks that fail
+// permanently, e.g. to keep their results elsewhere.
+func DeadLetter(hook DeadLetterHook) AsyncHTTPRequestHandlerOption {
+	return func(h *asyncHTTPHandler) { h.deadLetter = hook }
+}
+
 // AsyncHTTPRequestHandler creates a new asynchronous HTTPRequestHandler. The
-// given options are used to configure the handler.
+// given options are used to configure the handler. Callbacks carry the header
+// status with the JobStatus of the run. The callback of a failed run has a
+// JSON body with its error, e.g. {"error": "..."}, instead of the output.
 func AsyncHTTPRequestHandler(
 	options ...AsyncHTTPRequestHandlerOption,
 ) HTTPRequestHandler {
 	handler := &asyncHTTPHandler{
 		httpClient:      http.DefaultClient,
 		requestOverride: true,
+		retries:         3,
+		backoff:         500 * time.Millisecond,
+		maxBackoff:      30 * time.Second,
+		timeout:         30 * time.Second,
 	}
 	for _, option := range options {
 		option(handler)
@@ -57,6 +112,12 @@ type asyncHTTPHandler struct {
 	httpClient      *http.Client
 	callbackURL     string
 	requestOverride bool
+	retries         int
+	backoff         time.Duration
+	maxBackoff      time.Duration
+	timeout         time.Duration
+	secret          []byte
+	deadLetter      DeadLetterHook
 }
 
 func (a asyncHTTPHandler) Handler(
@@ -78,24 +139,22 @@ func (a asyncHTTPHandler) Handler(
 	}
 
 	buf := new(bytes.Buffer)
-	callbackFunc := func(requestID, contentType string) (err error) {
-		// Create a new request
-		callbackReq, err := http.NewRequestWithContext(
-			context.Background(), "POST", callbackURL, buf,
-		)
-		if err != nil {
-			return err
+	callbackFunc := func(
+		ctx context.Context, requestID, contentType string, runErr error,
+	) error {
+		status, body := JobSucceeded, buf.Bytes()
+		if runErr != nil {
+			status, contentType = JobFailed, "application/json"
+			var err error
+			body, err = json.Marshal(callbackError{Error: runErr.Error()})
+			if err != nil {
+				return err
+			}
 		}
-		// Set the GUID header
-		callbackReq.Header.Set("request_id", requestID)
-		// Set the encoding header
-		callbackReq.Header.Set("Content-Type", contentType)
-		// Send the request
-		resp, err := a.httpClient.Do(callbackReq)
-		if err != nil {
-			return err
+		err := a.callback(ctx, callbackURL, requestID, contentType, status, body)
+		if err != nil && a.deadLetter != nil {
+			a.deadLetter(requestID, contentType, body, err)
 		}
-		err = resp.Body.Close()
 		return err
 	}
 
@@ -114,3 +173,127 @@ func (a asyncHTTPHandler) Handler(
 		)
 	}, nil
 }
+
+// callbackError is the body of the callback of a failed run.
+type callbackError struct {
+	Error string `json:"error"`
+}
+
+// callback sends the body to the callback url and retries failed attempts
+// until ctx is done.
+func (a asyncHTTPHandler) callback(
+	ctx context.Context,
+	callbackURL, requestID, contentType string,
+	status JobStatus,
+	body []byte,
+) error {
+	for attempt := 0; ; attempt++ {
+		err := a.send(ctx, callbackURL, requestID, contentType, status, body)
+		var permanent permanentError
+		if err != nil && attempt < a.retries && !errors.As(err, &permanent) {
+			select {
+			case <-time.After(a.delay(attempt)):
+				continue
+			case <-ctx.Done():
+				err = errors.Join(err, context.Cause(ctx))
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf(
+				"callback for %s failed after %d attempts: %w",
+				requestID, attempt+1, err,
+			)
+		}
+		return err
+	}
+}
+
+// send makes a single attempt to send the body to the callback url.
+func (a asyncHTTPHandler) send(
+	ctx context.Context,
+	callbackURL, requestID, contentType string,
+	status JobStatus,
+	body []byte,
+) error {
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+	// Create a new request
+	callbackReq, err := http.NewRequestWithContext(
+		ctx, "POST", callbackURL, bytes.NewReader(body),
+	)
+	if err != nil {
+		return permanentError{err}
+	}
+	// Set the GUID header
+	callbackReq.Header.Set("request_id", requestID)
+	// Set the encoding header
+	callbackReq.Header.Set("Content-Type", contentType)
+	// Set the status header
+	callbackReq.Header.Set("status", string(status))
+	// Sign the request
+	if len(a.secret) > 0 {
+		callbackReq.Header.Set("signature", "sha256="+a.sign(requestID, body))
+	}
+	// Send the request
+	resp, err := a.httpClient.Do(callbackReq)
+	if err != nil {
+		return err
+	}
+	// drain the body, so that the connection can be reused
+	_, err = io.Copy(io.Discard, resp.Body)
+	err = errors.Join(err, resp.Body.Close())
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("callback responded with status %s", resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout &&
+			resp.StatusCode != http.StatusTooManyRequests {
+			return permanentError{err}
+		}
+	}
+	return err
+}
+
+// sign returns the hex encoded HMAC-SHA256 of the request id and the body.
+func (a asyncHTTPHandler) sign(requestID string, body []byte) string {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(requestID + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// delay returns the randomized delay before the retry after the given attempt.
+func (a asyncHTTPHandler) delay(attempt int) time.Duration {
+	delay := a.backoff
+	for i := 0; i < attempt && delay < a.maxBackoff; i++ {
+		delay *= 2
+	}
+	if a.maxBackoff > 0 && delay > a.maxBackoff {
+		delay = a.maxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// #nosec G404 -- the jitter does not need to be unpredictable
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// permanentError is a callback error which is not worth retrying.
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// VerifyCallback reports whether the signature header of a callback, as set
+// when using CallbackSecret, matches the request id and the body.
+func VerifyCallback(
+	secret []byte, requestID string, body []byte, signature string,
+) bool {
+	expected := "sha256=" + asyncHTTPHandler{secret: secret}.sign(requestID, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -2,23 +2,31 @@ package run
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/nextmv-io/sdk/run/decode"
 	"github.com/nextmv-io/sdk/run/encode"
 	"github.com/nextmv-io/sdk/run/validate"
 )
 
 // Callback is a function that is called after the request is processed. It is
-// used to send the result asynchronously to some other service. The first
-// argument is the request id. The second argument is the contentType, e.g.
-// application/json.
-type Callback func(requestID string, contentType string) error
+// used to send the result asynchronously to some other service. The context is
+// cancelled once the runner stopped and its active runs did not finish within
+// the shutdown grace period. The request id and the contentType, e.g.
+// application/json, describe the output. err is the error of a failed run,
+// which is reported instead of the output.
+type Callback func(
+	ctx context.Context, requestID, contentType string, err error,
+) error
 
 // HTTPRequestHandler is a function that handles an http request.
 type HTTPRequestHandler func(
@@ -73,6 +81,17 @@ func SetRunnerOption[Input, Option, Solution any](
 	}
 }
 
+// SetJobStore sets the store for the status and output of async runs. By
+// default they are kept in memory or in the directory given by the
+// -runner.jobs.dir flag.
+func SetJobStore[Input, Option, Solution any](
+	store JobStore,
+) func(*httpRunner[Input, Option, Solution]) {
+	return func(r *httpRunner[Input, Option, Solution]) {
+		r.jobStore = store
+	}
+}
+
 // SetHTTPServer sets the http server. Note that if you want to set the address
 // or the logger of the http server you are setting through this option and you
 // want to make use of SetAddr and SetLogger, you should pass them after passing
@@ -91,34 +110,66 @@ type HTTPRunner[RunnerConfig, Input, Option, Solution any] interface {
 	Runner[RunnerConfig, Input, Option, Solution]
 	// ActiveRuns returns the number of currently active runs.
 	ActiveRuns() int
+	// QueueDepth returns the number of requests waiting for a free slot.
+	QueueDepth() int
 }
 
-// NewHTTPRunner creates a new NewHTTPRunner.
+// NewHTTPRunner creates a new NewHTTPRunner. The input is decoded according
+// to the Content-Type of a request and the output is encoded in the most
+// preferred type of its Accept header. JSON, XML, Gob and CSV are supported
+// by default, see RegisterDecoder and RegisterEncoder. Only JSON inputs are
+// validated. Requests may be compressed with gzip and responses are, if the
+// client accepts it. If streaming is configured, every solution is sent to the
+// client as soon as the algorithm finds it, either as newline delimited JSON
+// or as Server-Sent Events. The runner describes its API as an OpenAPI
+// document at /openapi.json.
 func NewHTTPRunner[Input, Option, Solution any](
 	algorithm Algorithm[Input, Option, Solution],
 	options ...HTTPRunnerOption[Input, Option, Solution],
 ) HTTPRunner[HTTPRunnerConfig, Input, Option, Solution] {
-	runner := &httpRunner[Input, Option, Solution]{
-		// the IOProducer will be dynamically set by the http request handler.
-		Runner: GenericRunner[HTTPRunnerConfig](
-			nil,
-			GenericDecoder[Input](decode.JSON()),
-			validate.JSON[Input](nil),
-			QueryParamDecoder[Option],
-			algorithm,
-			GenericEncoder[Solution, Option](encode.JSON()),
-		),
+	runner, err := NewHTTPRunnerFromFlags(
+		flag.CommandLine, os.Args[1:], algorithm, options...,
+	)
+	if err != nil {
+		log.Fatal(err)
 	}
+	return runner
+}
 
-	runnerConfig := runner.Runner.RunnerConfig()
-	runner.maxParallel = make(chan struct{}, runnerConfig.Runner.HTTP.MaxParallel)
+// NewHTTPRunnerFromFlags creates a new HTTPRunner like NewHTTPRunner, but
+// parses its configuration from args with fs and returns an error instead of
+// exiting. The options are applied before the configuration is parsed. The
+// http server uses the configured address and read header timeout unless they
+// are set by an option.
+func NewHTTPRunnerFromFlags[Input, Option, Solution any](
+	fs *flag.FlagSet,
+	args []string,
+	algorithm Algorithm[Input, Option, Solution],
+	options ...HTTPRunnerOption[Input, Option, Solution],
+) (HTTPRunner[HTTPRunnerConfig, Input, Option, Solution], error) {
+	// the decoder and encoder are negotiated for every request
+	defaultEncoder := &negotiatingEncoder[Solution, Option]{}
+	generic := newGenericRunner[HTTPRunnerConfig](
+		// the IOProducer is created for every request by the request handler.
+		nil,
+		negotiatingDecoder[Input],
+		jsonValidator[Input](),
+		QueryParamDecoder[Option],
+		algorithm,
+		defaultEncoder,
+	)
+	runner := &httpRunner[Input, Option, Solution]{
+		Runner:         generic,
+		metrics:        newMetrics(),
+		codecs:         newCodecs(),
+		defaultEncoder: defaultEncoder,
+	}
+	runner.lifetime, runner.stop = context.WithCancelCause(context.Background())
 
 	// default http server
 	runner.httpServer = &http.Server{
-		ReadHeaderTimeout: runnerConfig.Runner.HTTP.ReadHeaderTimeout,
-		Addr:              runnerConfig.Runner.HTTP.Address,
-		ErrorLog:          log.New(os.Stderr, "[Nextmv HTTPRunner] ", log.LstdFlags),
-		Handler:           runner,
+		ErrorLog: log.New(os.Stderr, "[Nextmv HTTPRunner] ", log.LstdFlags),
+		Handler:  runner,
 	}
 
 	// default handler to IOProducer
@@ -128,14 +179,92 @@ func NewHTTPRunner[Input, Option, Solution any](
 		option(runner)
 	}
 
-	return runner
+	err := generic.configure(fs, args)
+	if err != nil {
+		return nil, err
+	}
+
+	runnerConfig := generic.RunnerConfig()
+	// an encoder set by an option takes precedence over streaming
+	if runnerConfig.Runner.HTTP.Stream != "" &&
+		generic.GetEncoder() == defaultEncoder {
+		encoder, err := streamEncoder(runnerConfig.Runner.HTTP.Stream)
+		if err != nil {
+			return nil, err
+		}
+		generic.SetEncoder(GenericEncoder[Solution, Option](encoder))
+	}
+	if runner.queue == nil {
+		runner.queue = newRequestQueue(runnerConfig.Runner.HTTP.MaxParallel)
+	}
+	runner.queue.configure(
+		runnerConfig.Runner.HTTP.Queue.Size,
+		runnerConfig.Runner.HTTP.Queue.Timeout,
+	)
+	if runner.httpServer.Addr == "" {
+		runner.httpServer.Addr = runnerConfig.Runner.HTTP.Address
+	}
+	if runner.httpServer.ReadHeaderTimeout == 0 {
+		runner.httpServer.ReadHeaderTimeout =
+			runnerConfig.Runner.HTTP.ReadHeaderTimeout
+	}
+	if runner.jobStore == nil {
+		runner.jobStore = NewMemoryJobStore(runnerConfig.Runner.Jobs.TTL)
+		if runnerConfig.Runner.Jobs.Dir != "" {
+			runner.jobStore, err = NewFileJobStore(
+				runnerConfig.Runner.Jobs.Dir, runnerConfig.Runner.Jobs.TTL,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	runner.mux = http.NewServeMux()
+	runner.mux.HandleFunc("/", runner.countRequests(runner.serveRun))
+	runner.mux.HandleFunc("/runs/", runner.serveJob)
+	runner.mux.HandleFunc("/healthz", runner.serveHealth)
+	runner.mux.HandleFunc("/readyz", runner.serveReady)
+	runner.mux.HandleFunc("/metrics", runner.serveMetrics)
+	runner.mux.HandleFunc("/openapi.json", runner.serveOpenAPI)
+
+	return runner, nil
+}
+
+// streamEncoder returns the encoder for the given stream format.
+func streamEncoder(format string) (encode.Encoder, error) {
+	switch format {
+	case "ndjson":
+		return encode.NDJSON(), nil
+	case "sse":
+		return encode.SSE(), nil
+	default:
+		return nil, errors.New(`stream must be "ndjson" or "sse"`)
+	}
 }
 
 type httpRunner[Input, Option, Solution any] struct {
 	Runner[HTTPRunnerConfig, Input, Option, Solution]
 	httpServer         *http.Server
-	maxParallel        chan struct{}
+	queue              *requestQueue
 	httpRequestHandler HTTPRequestHandler
+	mux                *http.ServeMux
+	jobStore           JobStore
+	// cancelJobs holds the cancel functions of the active async runs by id
+	cancelJobs sync.Map
+	// lifetime is cancelled once the runner stopped and the active runs
+	// did not finish within the shutdown grace period
+	lifetime context.Context
+	stop     context.CancelCauseFunc
+	// runs tracks the active sync and async runs
+	runs sync.WaitGroup
+	// shuttingDown is set once the runner stopped accepting requests
+	shuttingDown atomic.Bool
+	metrics      *metrics
+	openAPI      openAPI
+	codecs       codecs
+	// defaultEncoder negotiates the encoder, unless it is replaced
+	defaultEncoder Encoder[Solution, Option]
 }
 
 func (h *httpRunner[Input, Option, Solution]) setHTTPAddr(addr string) {
@@ -147,11 +276,15 @@ func (h *httpRunner[Input, Option, Solution]) setLogger(l *log.Logger) {
 }
 
 func (h *httpRunner[Input, Option, Solution]) setMaxParallel(maxParallel int) {
-	h.maxParallel = make(chan struct{}, maxParallel)
+	h.queue = newRequestQueue(maxParallel)
 }
 
 func (h *httpRunner[Input, Option, Solution]) ActiveRuns() int {
-	return len(h.maxParallel)
+	return h.queue.activeRuns()
+}
+
+func (h *httpRunner[Input, Option, Solution]) QueueDepth() int {
+	return h.queue.depth()
 }
 
 func (h *httpRunner[Input, Option, Solution]) setHTTPRequestHandler(
@@ -170,9 +303,55 @@ func (h *httpRunner[Input, Option, Solution]) setRunnerOption(
 	option(h.Runner)
 }
 
+// Run serves http requests until ctx is done. Then the server stops accepting
+// requests and the active runs have the shutdown grace period to finish.
+// Runs which are still active afterwards are cancelled, they encode the
+// solutions found so far.
 func (h *httpRunner[Input, Option, Solution]) Run(
-	_ context.Context,
+	ctx context.Context,
 ) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- h.listenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		h.stop(TerminationShutdown)
+		return err
+	case <-ctx.Done():
+	}
+	h.shuttingDown.Store(true)
+
+	grace := h.Runner.RunnerConfig().Runner.HTTP.Shutdown.Grace
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	// stop accepting requests, the active runs are awaited below
+	err := h.httpServer.Shutdown(shutdownCtx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		h.stop(TerminationShutdown)
+		<-done
+	}
+	h.stop(TerminationShutdown)
+
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+	return err
+}
+
+func (h *httpRunner[Input, Option, Solution]) listenAndServe() error {
 	httpRunnerConfig := h.Runner.RunnerConfig()
 	if httpRunnerConfig.Runner.HTTP.Certificate != "" ||
 		httpRunnerConfig.Runner.HTTP.Key != "" {
@@ -184,87 +363,206 @@ func (h *httpRunner[Input, Option, Solution]) Run(
 	return h.httpServer.ListenAndServe()
 }
 
-// ServeHTTP implements the http.Handler interface.
+// ServeHTTP implements the http.Handler interface. Paths below /runs/ query
+// and cancel async runs, see serveJob. /healthz and /readyz serve liveness and
+// readiness probes, /metrics serves metrics in the Prometheus text format and
+// /openapi.json serves the OpenAPI document of the runner. All other paths
+// start a run.
 func (h *httpRunner[Input, Option, Solution]) ServeHTTP(
 	w http.ResponseWriter, req *http.Request,
 ) {
-	select {
-	case h.maxParallel <- struct{}{}:
-	default:
-		// No free slot, so we immediately return an error.
-		http.Error(w, "max number of parallel requests exceeded",
-			http.StatusTooManyRequests)
+	h.mux.ServeHTTP(w, req)
+}
+
+// serveRun runs the algorithm for the request. Sync runs wait for a free slot
+// before they start. Async runs are saved as queued and their request id is
+// returned right away, they wait for a slot in the background.
+func (h *httpRunner[Input, Option, Solution]) serveRun(
+	w http.ResponseWriter, req *http.Request,
+) {
+	n, err := h.negotiate(req)
+	if err != nil {
+		handleError(h.httpServer.ErrorLog, false, err, w)
 		return
 	}
 
-	// control mechanism to let the request by run async or not.
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		defer func() { <-h.maxParallel }()
-		// configure how to turn the request and response into an IOProducer.
-		callbackFunc, producer, err := h.httpRequestHandler(w, req)
-		async := callbackFunc != nil
-		if err != nil {
-			handleError(h.httpServer.ErrorLog, async, err, w)
-			wg.Done()
-			return
-		}
-		// generate a new requestID
-		requestID := uuid.New().String()
+	if acceptsGzip(req) {
+		gzipWriter := newGzipResponseWriter(w)
+		defer func() {
+			if err := gzipWriter.close(); err != nil {
+				h.httpServer.ErrorLog.Println(err)
+			}
+		}()
+		w = gzipWriter
+	}
+
+	// configure how to turn the request and response into an IOProducer.
+	callbackFunc, producer, err := h.httpRequestHandler(w, req)
+	async := callbackFunc != nil
+	if err != nil {
+		handleError(h.httpServer.ErrorLog, async, err, w)
+		return
+	}
+	// generate a new requestID
+	requestID := uuid.New().String()
 
-		// get content type from the encoder
+	// get content type from the negotiation or the encoder
+	contentType := n.encoderType
+	if contentType == "" {
 		contentTyper, ok := h.Runner.GetEncoder().(ContentTyper)
 		if !ok {
 			handleError(h.httpServer.ErrorLog, async,
 				errors.New("encoder does not implement ContentTyper"), w)
-			wg.Done()
 			return
 		}
+		contentType = contentTyper.ContentType()
+	}
+	// run with the codecs of this request, the runner itself is shared by all
+	// requests
+	runWith := func(
+		ctx context.Context, producer IOProducer[HTTPRunnerConfig],
+	) error {
+		start := time.Now()
+		defer func() { h.queue.release(time.Since(start)) }()
+		ctx = withPhaseObserver(ctx, h.metrics.observe)
+		return h.Runner.RunWith(context.WithValue(ctx, negotiated, n), producer)
+	}
 
-		if async {
-			// write the guid to the response.
-			_, err = w.Write([]byte(requestID))
-			if err != nil {
-				handleError(h.httpServer.ErrorLog, async, err, w)
-				wg.Done()
-				return
-			}
-			wg.Done()
-		} else {
-			w.Header().Add("Content-Type", contentTyper.ContentType())
-			defer wg.Done()
+	if !async {
+		// wait for a free slot
+		if err := h.queue.acquire(req.Context()); err != nil {
+			h.rejectRequest(w, err)
+			return
 		}
-		if err != nil {
+		h.runs.Add(1)
+		defer h.runs.Done()
+		w.Header().Add("Content-Type", contentType)
+		if contentType == "text/event-stream" {
+			w.Header().Add("Cache-Control", "no-cache")
+		}
+		// sync runs end with the request
+		ctx, cancel := h.runContext(req.Context())
+		defer cancel()
+		if err := runWith(ctx, producer); err != nil {
 			handleError(h.httpServer.ErrorLog, async, err, w)
-			return
 		}
-		// get a copy of the genericRunner set the IOProducer and run it.
-		genericRunner := h.Runner
-		genericRunner.SetIOProducer(producer)
-		err = genericRunner.Run(context.Background())
+		return
+	}
+
+	if h.queue.saturated() {
+		h.rejectRequest(w, errQueueFull)
+		return
+	}
+	// track the run, so that it can be polled and cancelled while it waits
+	// for a slot and while it runs
+	ctx, producer, finishJob := h.startJob(requestID, contentType, producer)
+	// write the guid to the response.
+	if _, err := w.Write([]byte(requestID)); err != nil {
+		finishJob(err)
+		handleError(h.httpServer.ErrorLog, async, err, w)
+		return
+	}
+	h.runs.Add(1)
+	go func() {
+		defer h.runs.Done()
+		err := h.queue.acquire(ctx)
+		if err == nil {
+			err = runWith(ctx, producer)
+		}
+		job := finishJob(err)
 		if err != nil {
 			handleError(h.httpServer.ErrorLog, async, err, w)
+		}
+		// the client cancelled the run and does not expect a callback
+		if job.Status == JobCanceled {
 			return
 		}
-
-		// if the request is async, call the callbackFunc.
-		if async {
-			err = callbackFunc(requestID, contentTyper.ContentType())
-			if err != nil {
-				handleError(h.httpServer.ErrorLog, async, err, w)
-				return
-			}
+		// failed runs report their error to the callback
+		err = callbackFunc(h.lifetime, requestID, contentType, err)
+		if err != nil {
+			handleError(h.httpServer.ErrorLog, async, err, w)
 		}
 	}()
-	wg.Wait()
 }
 
+// negotiate selects the decoder and, unless the encoder is replaced, the
+// encoder for the request. A missing decoder is only an error once the input
+// is decoded, as the input decoder may be replaced as well.
+func (h *httpRunner[Input, Option, Solution]) negotiate(
+	req *http.Request,
+) (negotiation, error) {
+	n, err := h.codecs.negotiateDecoder(req)
+	if err != nil {
+		return n, err
+	}
+	if h.Runner.GetEncoder() != h.defaultEncoder {
+		return n, nil
+	}
+	return n, h.codecs.negotiateEncoder(req, &n)
+}
+
+// rejectRequest responds to a request which did not get a slot. Clients are
+// told when to retry, based on the recent run durations.
+func (h *httpRunner[Input, Option, Solution]) rejectRequest(
+	w http.ResponseWriter, err error,
+) {
+	status := http.StatusTooManyRequests
+	switch {
+	case errors.Is(err, errQueueTimeout):
+		status = http.StatusServiceUnavailable
+	case !errors.Is(err, errQueueFull):
+		// the client is gone, there is no one to respond to
+		return
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(h.queue.retryAfter()))
+	http.Error(w, err.Error(), status)
+}
+
+// validationErrorBody is the response body for an input that does not satisfy
+// the schema.
+type validationErrorBody struct {
+	Error      string               `json:"error"`
+	Violations []validate.Violation `json:"violations"`
+}
+
+// handleError logs the error and, if the request is not async, responds with
+// it. Invalid inputs are a client error, which is described in a JSON body.
+// Inputs and options which cannot be decoded and content types which cannot
+// be decoded or encoded are rejected as well, all other errors are internal
+// server errors.
 func handleError(log *log.Logger,
 	async bool, err error, w http.ResponseWriter,
 ) {
 	log.Println(err)
-	if !async {
+	if async {
+		return
+	}
+	switch {
+	case errors.Is(err, errUnsupportedMediaType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	case errors.Is(err, errNotAcceptable):
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	var decodeErr DecodeError
+	if errors.As(err, &decodeErr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var validationErr validate.Error
+	if !errors.As(err, &validationErr) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusBadRequest)
+	err = json.NewEncoder(w).Encode(validationErrorBody{
+		Error:      "input is invalid",
+		Violations: validationErr.Violations,
+	})
+	if err != nil {
+		log.Println(err)
 	}
 }
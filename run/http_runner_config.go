@@ -8,9 +8,10 @@ import (
 // HTTPRunnerConfig defines the configuration of the HTTPRunner.
 type HTTPRunnerConfig struct {
 	Runner struct {
-		Log    *log.Logger
+		Log    *log.Logger `flag:""`
 		Output struct {
 			Solutions string `default:"last" usage:"Return all or last solution"`
+			Timings   bool   `usage:"Add the timings of the run to the statistics of schema.Output solutions"`
 		}
 		HTTP struct {
 			Address           string        `default:":9000" usage:"The host address"`
@@ -18,11 +19,74 @@ type HTTPRunnerConfig struct {
 			Key               string        `usage:"The key file path"`
 			ReadHeaderTimeout time.Duration `default:"60s" usage:"The maximum duration for reading the request headers"`
 			MaxParallel       int           `default:"1" usage:"The max number of requests"`
+			Stream            string        `usage:"Stream all solutions as they are found {ndjson, sse}"`
+			Shutdown          struct {
+				Grace time.Duration `default:"30s" usage:"How long active runs may take to finish once the server stops"`
+			}
+			Queue struct {
+				Size    int           `usage:"The max number of requests waiting for a free slot"`
+				Timeout time.Duration `default:"30s" usage:"The max time a request waits for a free slot, 0 waits indefinitely"`
+			}
+		}
+		Limits struct {
+			Duration    time.Duration `usage:"The maximum duration of a run"`
+			Grace       time.Duration `default:"1s" usage:"How long a stopped algorithm may take to return before it is abandoned"`
+			Solutions   int           `usage:"The maximum number of solutions, a run stops once the algorithm sent them"`
+			Target      string        `usage:"The solution value at which a run stops"`
+			Improvement time.Duration `usage:"How long the solution value may not improve before a run stops"`
+			Objective   string        `default:"minimize" usage:"Whether a smaller or larger solution value is better {minimize, maximize}"`
+		}
+		Jobs struct {
+			TTL time.Duration `default:"1h" usage:"How long the status and output of async runs are kept after they finished"`
+			Dir string        `usage:"The directory to keep async runs in, in memory if empty"`
+		}
+		Replay struct {
+			Diff   bool     `usage:"Run the algorithm on inputs with a recorded output and compare the last solution with it"`
+			Ignore []string `default:".statistics.run.duration,.statistics.run.custom.timings,.statistics.result.duration,.statistics.series_data" usage:"The keys not compared with the recorded output"`
 		}
 	}
 }
 
-// Solutions returns the configured solutions.
+// DurationLimit returns the maximum duration of a run.
+func (c HTTPRunnerConfig) DurationLimit() time.Duration {
+	return c.Runner.Limits.Duration
+}
+
+// GracePeriod returns how long a stopped algorithm may take to return.
+func (c HTTPRunnerConfig) GracePeriod() time.Duration {
+	return c.Runner.Limits.Grace
+}
+
+// Criteria returns the criteria under which a run is stopped early.
+func (c HTTPRunnerConfig) Criteria() (Criteria, error) {
+	limits := c.Runner.Limits
+	return parseCriteria(
+		limits.Solutions, limits.Target, limits.Objective, limits.Improvement,
+	)
+}
+
+// ReplayDiff reports whether the algorithm is run on inputs with a recorded
+// output to compare its last solution with it.
+func (c HTTPRunnerConfig) ReplayDiff() bool {
+	return c.Runner.Replay.Diff
+}
+
+// ReplayIgnore returns the keys not compared with the recorded output.
+func (c HTTPRunnerConfig) ReplayIgnore() []string {
+	return c.Runner.Replay.Ignore
+}
+
+// ReportTimings reports whether the timings of the run are added to the
+// statistics of schema.Output solutions.
+func (c HTTPRunnerConfig) ReportTimings() bool {
+	return c.Runner.Output.Timings
+}
+
+// Solutions returns the configured solutions. All solutions are returned when
+// streaming.
 func (c HTTPRunnerConfig) Solutions() (Solutions, error) {
+	if c.Runner.HTTP.Stream != "" {
+		return All, nil
+	}
 	return ParseSolutions(c.Runner.Output.Solutions)
 }
@@ -0,0 +1,140 @@
+package run_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
+type message struct {
+	Message string `json:"message"`
+}
+
+// TestHTTPRunnerParallel sends many requests in parallel, each one must get
+// the output for its own input. Run it with -race.
+func TestHTTPRunnerParallel(t *testing.T) {
+	const requests = 64
+	algorithm := func(
+		_ context.Context, input message, _ testOption, solutions chan<- message,
+	) error {
+		solutions <- message{Message: input.Message + " World!"}
+		return nil
+	}
+	runner, err := run.NewHTTPRunnerFromFlags(
+		newFlagSet(),
+		[]string{"-runner.http.maxparallel", fmt.Sprint(requests)},
+		algorithm,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, ok := runner.(http.Handler)
+	if !ok {
+		t.Fatal("HTTPRunner is not an http.Handler")
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := fmt.Sprintf(`{"message": "Hello %d"}`, i)
+			req, err := http.NewRequestWithContext(
+				context.Background(),
+				http.MethodPost,
+				server.URL,
+				strings.NewReader(input),
+			)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			want := fmt.Sprintf(`{"message":"Hello %d World!"}`, i)
+			if strings.TrimSpace(string(body)) != want {
+				t.Errorf("got %s, want %s", body, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+type requestOption struct {
+	Verbose bool `json:"verbose" default:"true"`
+	Solve   struct {
+		Iterations int `json:"iterations" default:"10"`
+	} `json:"solve"`
+}
+
+// TestHTTPRunnerRequestOptions checks that the query parameters of a request
+// override the defaults, also with zero values.
+func TestHTTPRunnerRequestOptions(t *testing.T) {
+	algorithm := func(
+		_ context.Context, _ message, option requestOption,
+		solutions chan<- requestOption,
+	) error {
+		solutions <- option
+		return nil
+	}
+	runner, err := run.NewHTTPRunnerFromFlags(newFlagSet(), nil, algorithm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, ok := runner.(http.Handler)
+	if !ok {
+		t.Fatal("HTTPRunner is not an http.Handler")
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", `{"verbose":true,"solve":{"iterations":10}}`},
+		{"?verbose=false", `{"verbose":false,"solve":{"iterations":10}}`},
+		{"?Solve.Iterations=0", `{"verbose":true,"solve":{"iterations":0}}`},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(
+			context.Background(),
+			http.MethodPost,
+			server.URL+test.query,
+			strings.NewReader(`{"message": "Hello"}`),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.TrimSpace(string(body)); got != test.want {
+			t.Errorf("query %q: got %s, want %s", test.query, got, test.want)
+		}
+	}
+}
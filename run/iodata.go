@@ -77,7 +77,7 @@ type ioData struct {
 
 func (d ioData) Input() (input any) {
 	// buffer was filled so use that instead of the original reader
-	if len(d.buf.Bytes()) > 0 {
+	if d.buf != nil && d.buf.Len() > 0 {
 		return bytes.NewReader(d.buf.Bytes())
 	}
 	return d.input
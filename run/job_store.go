@@ -0,0 +1,148 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// JobStatus is the status of an async run.
+type JobStatus string
+
+// Statuses of an async run.
+const (
+	// JobQueued is the status of a run which has not started yet.
+	JobQueued JobStatus = "queued"
+	// JobRunning is the status of a run whose algorithm is running.
+	JobRunning JobStatus = "running"
+	// JobSucceeded is the status of a run whose output is stored.
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed is the status of a run which returned an error.
+	JobFailed JobStatus = "failed"
+	// JobCanceled is the status of a run which was cancelled by the client.
+	// The output found until then is stored.
+	JobCanceled JobStatus = "canceled"
+)
+
+// finished reports whether the status is final.
+func (s JobStatus) finished() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCanceled
+}
+
+// Job describes an async run of the HTTPRunner. Its ID is the request id
+// returned to the client.
+type Job struct {
+	ID          string    `json:"id"`
+	Status      JobStatus `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ErrJobNotFound is returned by a JobStore for unknown or expired jobs.
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStore stores the status and the encoded output of async runs, so that
+// clients can poll them instead of relying on a callback.
+type JobStore interface {
+	// Save creates or updates the job.
+	Save(ctx context.Context, job Job) error
+	// Job returns the job with the given id or ErrJobNotFound.
+	Job(ctx context.Context, id string) (Job, error)
+	// SaveResult stores the encoded output of the job with the given id.
+	SaveResult(ctx context.Context, id string, result []byte) error
+	// Result returns the encoded output of the job with the given id or
+	// ErrJobNotFound.
+	Result(ctx context.Context, id string) ([]byte, error)
+	// Delete removes the job with the given id and its output.
+	Delete(ctx context.Context, id string) error
+}
+
+// NewMemoryJobStore creates a JobStore which keeps jobs in memory. Finished
+// jobs expire ttl after they finished, a ttl of zero keeps them forever.
+// Queued and running jobs never expire.
+func NewMemoryJobStore(ttl time.Duration) JobStore {
+	return &memoryJobStore{
+		ttl:     ttl,
+		jobs:    map[string]Job{},
+		results: map[string][]byte{},
+	}
+}
+
+type memoryJobStore struct {
+	ttl     time.Duration
+	mutex   sync.Mutex
+	jobs    map[string]Job
+	results map[string][]byte
+}
+
+func (s *memoryJobStore) Save(_ context.Context, job Job) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expire()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *memoryJobStore) Job(_ context.Context, id string) (Job, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expire()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (s *memoryJobStore) SaveResult(
+	_ context.Context, id string, result []byte,
+) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.jobs[id]; !ok {
+		return ErrJobNotFound
+	}
+	s.results[id] = result
+	return nil
+}
+
+func (s *memoryJobStore) Result(_ context.Context, id string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expire()
+	result, ok := s.results[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return result, nil
+}
+
+func (s *memoryJobStore) Delete(_ context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.jobs, id)
+	delete(s.results, id)
+	return nil
+}
+
+// expire removes all expired jobs. The mutex must be held.
+func (s *memoryJobStore) expire() {
+	if s.ttl <= 0 {
+		return
+	}
+	for id, job := range s.jobs {
+		if expired(job, s.ttl) {
+			delete(s.jobs, id)
+			delete(s.results, id)
+		}
+	}
+}
+
+// expired reports whether the job finished more than ttl ago. The last update
+// of a finished job is the time it finished.
+func expired(job Job, ttl time.Duration) bool {
+	return ttl > 0 && job.Status.finished() && time.Since(job.UpdatedAt) > ttl
+}
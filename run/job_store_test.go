@@ -0,0 +1,85 @@
+package run_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
+func TestJobStores(t *testing.T) {
+	fileStore, err := run.NewFileJobStore(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores := map[string]run.JobStore{
+		"memory": run.NewMemoryJobStore(time.Hour),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			job := run.Job{
+				ID:        "1",
+				Status:    run.JobSucceeded,
+				UpdatedAt: time.Now(),
+			}
+			if err := store.Save(ctx, job); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.SaveResult(ctx, "1", []byte("result")); err != nil {
+				t.Fatal(err)
+			}
+			got, err := store.Job(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Status != run.JobSucceeded {
+				t.Errorf("got status %s, want %s", got.Status, run.JobSucceeded)
+			}
+			result, err := store.Result(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != "result" {
+				t.Errorf("got result %q, want %q", result, "result")
+			}
+
+			// expired jobs are removed, running jobs never expire
+			expired := run.Job{
+				ID:        "2",
+				Status:    run.JobFailed,
+				UpdatedAt: time.Now().Add(-2 * time.Hour),
+			}
+			if err := store.Save(ctx, expired); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Job(ctx, "2"); !errors.Is(err, run.ErrJobNotFound) {
+				t.Errorf("got error %v, want %v", err, run.ErrJobNotFound)
+			}
+			running := run.Job{
+				ID:        "3",
+				Status:    run.JobRunning,
+				UpdatedAt: time.Now().Add(-2 * time.Hour),
+			}
+			if err := store.Save(ctx, running); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Job(ctx, "3"); err != nil {
+				t.Errorf("got error %v for a running job", err)
+			}
+
+			if err := store.Delete(ctx, "1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Result(ctx, "1"); !errors.Is(err, run.ErrJobNotFound) {
+				t.Errorf("got error %v, want %v", err, run.ErrJobNotFound)
+			}
+			if _, err := store.Job(ctx, "../1"); !errors.Is(err, run.ErrJobNotFound) {
+				t.Errorf("got error %v, want %v", err, run.ErrJobNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package run
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// OptionSource is the layer of the configuration that supplied the value of an
+// option. Layers are applied in the order default, env, flag, file and
+// request, each one overriding the fields it sets in the layers before it. A
+// configuration supplied with Configure replaces all layers but the request.
+type OptionSource string
+
+// Option sources in the order in which they are applied.
+const (
+	// SourceDefault is the default struct tag or the zero value of a field.
+	SourceDefault OptionSource = "default"
+	// SourceEnv is an environment variable.
+	SourceEnv OptionSource = "env"
+	// SourceFlag is a command line flag.
+	SourceFlag OptionSource = "flag"
+	// SourceFile is the file given by the -runner.config flag.
+	SourceFile OptionSource = "file"
+	// SourceConfig is the configuration supplied with Configure instead of
+	// parsing flags.
+	SourceConfig OptionSource = "config"
+	// SourceRequest are the options decoded for a single run, e.g. from the
+	// query parameters of an http request.
+	SourceRequest OptionSource = "request"
+)
+
+// OptionSources maps the name of every option field to the layer that supplied
+// its value. The names are the flag names, e.g. "solve.duration" for the field
+// Duration of the nested struct Solve.
+type OptionSources map[string]OptionSource
+
+// isSet reports whether the field with the given flag name and value is set by
+// a layer.
+type isSet func(name string, value reflect.Value) bool
+
+// nonZero reports fields as set if they are not the zero value of their type.
+// Such a layer cannot reset a field to its zero value.
+func nonZero(_ string, value reflect.Value) bool {
+	return !value.IsZero()
+}
+
+// requestOptions reports the fields of the options of a request as set, if
+// the request has a value for them. This is known for query parameters,
+// other requests are merged with nonZero.
+func requestOptions[Option any](request any) isSet {
+	values, ok := request.(url.Values)
+	if !ok {
+		return nonZero
+	}
+	present := make(map[string]bool, len(values))
+	for key := range values {
+		// like the QueryParamDecoder, keys are not case sensitive
+		present[strings.ToLower(key)] = true
+	}
+	names := map[string]string{}
+	queryNames(reflect.TypeOf(new(Option)).Elem(), "", "", names)
+	return func(name string, _ reflect.Value) bool {
+		query, ok := names[name]
+		return ok && present[query]
+	}
+}
+
+// queryNames maps the flag names of the leaf fields of t to the lower case
+// names of their query parameters, see queryParameters.
+func queryNames(t reflect.Type, flagPrefix, queryPrefix string, names map[string]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field, flagPrefix)
+		if !ok {
+			continue
+		}
+		query := strings.ToLower(field.Name)
+		if tag := strings.Split(field.Tag.Get("schema"), ",")[0]; tag != "" {
+			query = strings.ToLower(tag)
+		}
+		if query == "-" {
+			continue
+		}
+		if queryPrefix != "" {
+			query = queryPrefix + "." + query
+		}
+		if isNested(field.Type) || isNestedPointer(field.Type) {
+			queryNames(field.Type, name, query, names)
+			continue
+		}
+		names[name] = query
+	}
+}
+
+// mergeOption returns base with every field replaced that is set in override,
+// according to set. Nested structs are merged field by field. The sources of
+// the replaced fields are set to source, the sources of base are not
+// modified.
+func mergeOption[Option any](
+	base Option,
+	baseSources OptionSources,
+	override Option,
+	source OptionSource,
+	set isSet,
+) (Option, OptionSources) {
+	sources := make(OptionSources, len(baseSources))
+	for name, s := range baseSources {
+		sources[name] = s
+	}
+
+	merged := base
+	dst := reflect.ValueOf(&merged).Elem()
+	src := reflect.ValueOf(&override).Elem()
+	if dst.Kind() != reflect.Struct {
+		if !src.IsZero() {
+			return override, sources
+		}
+		return merged, sources
+	}
+	mergeFields(dst, src, "", set, func(name string) { sources[name] = source })
+	return merged, sources
+}
+
+// mergeFields sets every leaf field of dst to the value in src, if that value
+// is set, and calls onSet with the name of the field.
+func mergeFields(
+	dst, src reflect.Value, prefix string, set isSet, onSet func(string),
+) {
+	for i := 0; i < dst.NumField(); i++ {
+		name, ok := fieldName(dst.Type().Field(i), prefix)
+		if !ok {
+			continue
+		}
+		dstField, srcField := dst.Field(i), src.Field(i)
+		switch {
+		case isNested(dstField.Type()):
+			mergeFields(dstField, srcField, name, set, onSet)
+		case isNestedPointer(dstField.Type()):
+			if srcField.IsNil() {
+				continue
+			}
+			// copy the struct, so that base is not modified
+			nested := reflect.New(dstField.Type().Elem())
+			if !dstField.IsNil() {
+				nested.Elem().Set(dstField.Elem())
+			}
+			mergeFields(nested.Elem(), srcField.Elem(), name, set, onSet)
+			dstField.Set(nested)
+		case set(name, srcField):
+			dstField.Set(srcField)
+			onSet(name)
+		}
+	}
+}
+
+// optionFields calls fn for every leaf field of v with its flag name.
+func optionFields(v reflect.Value, prefix string, fn func(string, reflect.Value)) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < v.NumField(); i++ {
+		name, ok := fieldName(v.Type().Field(i), prefix)
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		if isNested(field.Type()) || isNestedPointer(field.Type()) {
+			optionFields(field, name, fn)
+			continue
+		}
+		fn(name, field)
+	}
+}
+
+// fieldName returns the flag name of the field. Unexported fields and fields
+// which are skipped with an empty flag tag are not options.
+func fieldName(field reflect.StructField, prefix string) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	if override, ok := field.Tag.Lookup("flag"); ok {
+		if override == "" {
+			return "", false
+		}
+		// like flag names, only leaf fields can be renamed
+		if !isNested(field.Type) && !isNestedPointer(field.Type) {
+			return override, true
+		}
+	}
+	name := strings.ToLower(field.Name)
+	if prefix != "" {
+		name = prefix + "." + name
+	}
+	return name, true
+}
+
+// isNested reports whether t is a struct whose fields are options themselves.
+// Structs with unexported fields, such as time.Time, are treated as values.
+func isNested(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+// isNestedPointer reports whether t is a pointer to a nested struct.
+func isNestedPointer(t reflect.Type) bool {
+	return t.Kind() == reflect.Pointer && isNested(t.Elem())
+}
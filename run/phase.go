@@ -0,0 +1,276 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/nextmv-io/sdk/run/schema"
+	"github.com/nextmv-io/sdk/run/statistics"
+)
+
+// phase is a step of a run whose duration is observed.
+type phase string
+
+// Phases of a run.
+const (
+	// phaseIO covers producing the input, option and writer of the run.
+	phaseIO       phase = "io"
+	phaseValidate phase = "validate"
+	// phaseDecode covers decoding the input and the option.
+	phaseDecode phase = "decode"
+	// phaseAlgorithm lasts until the algorithm returned, even if the runner
+	// abandoned it before.
+	phaseAlgorithm phase = "algorithm"
+	// phaseEncode lasts while the encoder runs. It overlaps with the
+	// algorithm, as the encoder receives the solutions while they are found.
+	phaseEncode phase = "encode"
+)
+
+// phases lists all phases in the order in which they start.
+var phases = []phase{
+	phaseIO, phaseValidate, phaseDecode, phaseAlgorithm, phaseEncode,
+}
+
+type phaseObserverKey string
+
+// observerKey is the context key of the phaseObserver of a run.
+const observerKey phaseObserverKey = "phase_observer"
+
+// phaseObserver is called with the duration of every phase of a run.
+type phaseObserver func(phase, time.Duration)
+
+// withPhaseObserver returns a context which makes the runner report the
+// duration of every phase to observe.
+func withPhaseObserver(
+	ctx context.Context, observe phaseObserver,
+) context.Context {
+	return context.WithValue(ctx, observerKey, observe)
+}
+
+// observePhase reports the time since start as the duration of p, if ctx
+// carries a phaseObserver, and records it in the Timings of the run.
+func observePhase(ctx context.Context, p phase, start time.Time) {
+	duration := time.Since(start)
+	if t, ok := ctx.Value(timerKey).(*timer); ok {
+		t.record(p, duration)
+	}
+	if observe, ok := ctx.Value(observerKey).(phaseObserver); ok {
+		observe(p, duration)
+	}
+}
+
+// Timings are the durations of the steps of a run, as measured by the runner.
+// Steps which did not happen or did not finish yet have a zero duration. In
+// JSON, the durations are given in seconds.
+type Timings struct {
+	// IO is the time it took to produce the input, option and writer.
+	IO time.Duration
+	// Validate is the time it took to validate the input.
+	Validate time.Duration
+	// Decode is the time it took to decode the input and the option.
+	Decode time.Duration
+	// Algorithm is the time until the algorithm returned.
+	Algorithm time.Duration
+	// FirstSolution is the time from the start of the algorithm until it
+	// sent its first solution.
+	FirstSolution time.Duration
+	// LastSolution is the time from the start of the algorithm until it sent
+	// its last solution so far.
+	LastSolution time.Duration
+	// Encode is the time it took to encode the solutions. It overlaps with
+	// the algorithm, as the solutions are encoded while they are found. It is
+	// only known once the solutions are encoded, so it cannot appear in the
+	// statistics written into the output being encoded, see
+	// WithTimingsObserver for the final Timings.
+	Encode time.Duration
+}
+
+// MarshalJSON encodes the durations in seconds.
+func (t Timings) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		IO            float64 `json:"io"`
+		Validate      float64 `json:"validate"`
+		Decode        float64 `json:"decode"`
+		Algorithm     float64 `json:"algorithm"`
+		FirstSolution float64 `json:"first_solution"`
+		LastSolution  float64 `json:"last_solution"`
+		Encode        float64 `json:"encode,omitempty"`
+	}{
+		IO:            t.IO.Seconds(),
+		Validate:      t.Validate.Seconds(),
+		Decode:        t.Decode.Seconds(),
+		Algorithm:     t.Algorithm.Seconds(),
+		FirstSolution: t.FirstSolution.Seconds(),
+		LastSolution:  t.LastSolution.Seconds(),
+		Encode:        t.Encode.Seconds(),
+	})
+}
+
+// TimingsOf returns the Timings of the run of ctx measured so far. The
+// algorithm may call it to report them itself, e.g. at the end of its run.
+// It returns zero Timings for contexts which do not belong to a run.
+func TimingsOf(ctx context.Context) Timings {
+	t, ok := ctx.Value(timerKey).(*timer)
+	if !ok {
+		return Timings{}
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.timings
+}
+
+type timingsObserverKey string
+
+// timingsObserver is the context key of the observer of the final Timings.
+const timingsObserver timingsObserverKey = "timings_observer"
+
+// WithTimingsObserver returns a context which makes the runner call observe
+// with the Timings of every run of ctx once its solutions are encoded. Unlike
+// TimingsOf and the statistics reported in the output, these include Encode.
+func WithTimingsObserver(
+	ctx context.Context, observe func(Timings),
+) context.Context {
+	return context.WithValue(ctx, timingsObserver, observe)
+}
+
+// observeTimings reports the final Timings of the run of ctx to the observer
+// of ctx, if there is one.
+func observeTimings(ctx context.Context) {
+	if observe, ok := ctx.Value(timingsObserver).(func(Timings)); ok {
+		observe(TimingsOf(ctx))
+	}
+}
+
+type timerKeyType string
+
+// timerKey is the context key of the timer of a run.
+const timerKey timerKeyType = "timer"
+
+// timer records the Timings of a run. The algorithm and the encoder run
+// concurrently, so access is synchronized.
+type timer struct {
+	mutex          sync.Mutex
+	timings        Timings
+	algorithmStart time.Time
+	// values of the solutions, see Valuer
+	values []statistics.DataPoint
+}
+
+// withTimer returns a context which records the Timings of a run.
+func withTimer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, timerKey, &timer{})
+}
+
+func (t *timer) record(p phase, duration time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	switch p {
+	case phaseIO:
+		t.timings.IO = duration
+	case phaseValidate:
+		t.timings.Validate = duration
+	case phaseDecode:
+		t.timings.Decode = duration
+	case phaseAlgorithm:
+		t.timings.Algorithm = duration
+	case phaseEncode:
+		t.timings.Encode = duration
+	}
+}
+
+// startAlgorithm records the start of the algorithm of the run of ctx, which
+// the times of the solutions are measured from.
+func startAlgorithm(ctx context.Context, start time.Time) {
+	if t, ok := ctx.Value(timerKey).(*timer); ok {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		t.algorithmStart = start
+	}
+}
+
+// observeSolution records that the algorithm of the run of ctx sent the
+// solution, and its value.
+func observeSolution(ctx context.Context, solution any) {
+	t, ok := ctx.Value(timerKey).(*timer)
+	if !ok {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	elapsed := time.Since(t.algorithmStart)
+	if t.timings.FirstSolution == 0 {
+		t.timings.FirstSolution = elapsed
+	}
+	t.timings.LastSolution = elapsed
+	t.recordValue(solution, elapsed)
+}
+
+// timingsKey is the key of the Timings in the custom run statistics.
+const timingsKey = "timings"
+
+// reportTimings adds the Timings of the run of ctx to the statistics of the
+// solution, if it is a schema.Output. The run duration is set unless the
+// algorithm set it itself, and the Timings are added under the timings key of
+// the custom run statistics, if those are empty or a map.
+func reportTimings[Solution any](ctx context.Context, solution Solution) Solution {
+	return updateStatistics(solution, func(stats *statistics.Statistics) {
+		run := statistics.Run{}
+		if stats.Run != nil {
+			run = *stats.Run
+		}
+		if start, ok := ctx.Value(Start).(time.Time); ok && run.Duration == nil {
+			duration := time.Since(start).Seconds()
+			run.Duration = &duration
+		}
+		switch custom := run.Custom.(type) {
+		case nil:
+			run.Custom = map[string]any{timingsKey: TimingsOf(ctx)}
+		case map[string]any:
+			copied := make(map[string]any, len(custom)+1)
+			for key, value := range custom {
+				copied[key] = value
+			}
+			copied[timingsKey] = TimingsOf(ctx)
+			run.Custom = copied
+		}
+		stats.Run = &run
+	})
+}
+
+// updateStatistics calls update with a copy of the statistics of the solution,
+// if it is a schema.Output, and returns the solution with the updated copy.
+// update must copy the sections it changes, so that statistics shared with the
+// algorithm are not modified. Other solutions are returned unchanged.
+func updateStatistics[Solution any](
+	solution Solution, update func(*statistics.Statistics),
+) Solution {
+	var output schema.Output
+	switch s := any(solution).(type) {
+	case schema.Output:
+		output = s
+	case *schema.Output:
+		if s == nil {
+			return solution
+		}
+		output = *s
+	default:
+		return solution
+	}
+
+	updated := statistics.NewStatistics()
+	if output.Statistics != nil {
+		*updated = *output.Statistics
+	}
+	update(updated)
+	output.Statistics = updated
+
+	if s, ok := any(output).(Solution); ok {
+		return s
+	}
+	if s, ok := any(&output).(Solution); ok {
+		return s
+	}
+	return solution
+}
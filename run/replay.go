@@ -0,0 +1,188 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nextmv-io/sdk/flatmap"
+	"github.com/nextmv-io/sdk/run/validate"
+)
+
+// Replayer is the interface a runner configuration can implement to control
+// how inputs which carry a recorded output under validate.RecordedOutputKey
+// are handled. By default the recorded output is encoded instead of running
+// the algorithm.
+type Replayer interface {
+	// ReplayDiff reports whether the algorithm is run anyway and its last
+	// solution is compared with the recorded output.
+	ReplayDiff() bool
+	// ReplayIgnore returns the keys which are not compared, e.g.
+	// ".statistics.run.duration". A key also ignores all keys nested in it.
+	ReplayIgnore() []string
+}
+
+// ReplayDiffError is returned if the last solution of a run differs from the
+// output recorded in its input.
+type ReplayDiffError struct {
+	// Differences describe every key whose value differs.
+	Differences []string
+}
+
+func (e ReplayDiffError) Error() string {
+	return "solution differs from recorded output:\n" +
+		strings.Join(e.Differences, "\n")
+}
+
+// recordedOutput returns the output recorded in the input under
+// validate.RecordedOutputKey, if there is one. Inputs that are not a JSON
+// object never carry a recorded output.
+func recordedOutput(input any) (json.RawMessage, bool, error) {
+	reader, ok := input.(io.Reader)
+	if !ok {
+		return nil, false, nil
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false, err
+	}
+	// avoid decoding inputs which cannot carry a recorded output
+	if !bytes.Contains(data, []byte(validate.RecordedOutputKey)) {
+		return nil, false, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, false, nil
+	}
+	recorded, ok := fields[validate.RecordedOutputKey]
+	return recorded, ok, nil
+}
+
+// replay encodes the recorded output as the only solution of the run.
+func (r *genericRunner[RunnerConfig, Input, Option, Solution]) replay(
+	ctx context.Context, recorded json.RawMessage, writer any, option Option,
+) error {
+	var solution Solution
+	if err := json.Unmarshal(recorded, &solution); err != nil {
+		return fmt.Errorf("decoding recorded output: %w", err)
+	}
+	solutions := make(chan Solution, 1)
+	solutions <- solution
+	close(solutions)
+	return r.Encoder.Encode(ctx, solutions, writer, r.runnerConfig, option)
+}
+
+// keepLast forwards all solutions and yields the last one, if any, once the
+// solutions channel is closed.
+func keepLast[Solution any](
+	solutions <-chan Solution,
+) (<-chan Solution, <-chan *Solution) {
+	forwarded := make(chan Solution)
+	last := make(chan *Solution, 1)
+	go func() {
+		defer close(last)
+		defer close(forwarded)
+		var lastSolution *Solution
+		for solution := range solutions {
+			solution := solution
+			lastSolution = &solution
+			forwarded <- solution
+		}
+		last <- lastSolution
+	}()
+	return forwarded, last
+}
+
+// compareRecorded compares the JSON representation of the solution with the
+// recorded output and returns a ReplayDiffError if they differ.
+func compareRecorded(
+	recorded json.RawMessage, solution any, ignore []string,
+) error {
+	data, err := json.Marshal(solution)
+	if err != nil {
+		return err
+	}
+	var want, got any
+	if err := json.Unmarshal(recorded, &want); err != nil {
+		return fmt.Errorf("decoding recorded output: %w", err)
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		return err
+	}
+
+	wantFlat, gotFlat := flatten(want), flatten(got)
+	keys := map[string]bool{}
+	for key := range wantFlat {
+		keys[key] = true
+	}
+	for key := range gotFlat {
+		keys[key] = true
+	}
+
+	differences := []string{}
+	for key := range keys {
+		if isIgnored(key, ignore) {
+			continue
+		}
+		wantValue, wantOK := wantFlat[key]
+		gotValue, gotOK := gotFlat[key]
+		if wantOK == gotOK && reflect.DeepEqual(wantValue, gotValue) {
+			continue
+		}
+		differences = append(
+			differences,
+			fmt.Sprintf(
+				"%s: recorded %s, got %s",
+				key, describe(wantValue, wantOK), describe(gotValue, gotOK),
+			),
+		)
+	}
+	if len(differences) == 0 {
+		return nil
+	}
+	sort.Strings(differences)
+	return ReplayDiffError{Differences: differences}
+}
+
+// flatten flattens a decoded JSON value into keys like ".solutions[0].value",
+// values which are not an object are stored under the root key ".".
+func flatten(v any) map[string]any {
+	nested, ok := v.(map[string]any)
+	if !ok {
+		return map[string]any{".": v}
+	}
+	flattened := map[string]any{}
+	for key, value := range flatmap.Do(nested, flatmap.Options{}) {
+		flattened["."+key] = value
+	}
+	return flattened
+}
+
+// isIgnored reports whether key is one of the ignored keys or nested in one.
+func isIgnored(key string, ignore []string) bool {
+	for _, i := range ignore {
+		if key == i ||
+			strings.HasPrefix(key, i+".") ||
+			strings.HasPrefix(key, i+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// describe formats a flattened value for a ReplayDiffError.
+func describe(v any, ok bool) string {
+	if !ok {
+		return "nothing"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,152 @@
+package run
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	// errQueueFull is returned by acquire if no slot is free and the queue is
+	// full.
+	errQueueFull = errors.New("max number of parallel requests exceeded")
+	// errQueueTimeout is returned by acquire if no slot became free within
+	// the queue timeout.
+	errQueueTimeout = errors.New("timed out waiting for a free slot")
+)
+
+// requestQueue limits the number of parallel runs. Requests that find all
+// slots busy wait in a FIFO queue of bounded size for a slot to become free.
+type requestQueue struct {
+	mutex    sync.Mutex
+	slots    int
+	active   int
+	waiting  *list.List
+	size     int
+	timeout  time.Duration
+	duration time.Duration
+}
+
+// newRequestQueue creates a queue with the given number of slots and no room
+// for waiting requests.
+func newRequestQueue(slots int) *requestQueue {
+	return &requestQueue{slots: slots, waiting: list.New()}
+}
+
+// configure sets the number of requests that may wait for a slot and how long
+// they wait at most. A timeout of zero lets them wait until they are
+// cancelled.
+func (q *requestQueue) configure(size int, timeout time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.size = size
+	q.timeout = timeout
+}
+
+// acquire takes a free slot or waits for one in the queue. It returns
+// errQueueFull, errQueueTimeout or the error of ctx if it did not get a slot.
+func (q *requestQueue) acquire(ctx context.Context) error {
+	q.mutex.Lock()
+	if q.active < q.slots && q.waiting.Len() == 0 {
+		q.active++
+		q.mutex.Unlock()
+		return nil
+	}
+	if q.waiting.Len() >= q.size {
+		q.mutex.Unlock()
+		return errQueueFull
+	}
+	granted := make(chan struct{})
+	element := q.waiting.PushBack(granted)
+	timeout := q.timeout
+	q.mutex.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	err := errQueueTimeout
+	select {
+	case <-granted:
+		return nil
+	case <-expired:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	select {
+	case <-granted:
+		// the slot was granted while giving up, so it must be returned
+		q.handOver()
+	default:
+		q.waiting.Remove(element)
+	}
+	return err
+}
+
+// release returns a slot after a run which held it for the given duration.
+func (q *requestQueue) release(duration time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	// exponentially weighted moving average of the run durations
+	if q.duration == 0 {
+		q.duration = duration
+	} else {
+		q.duration = time.Duration(0.2*float64(duration) + 0.8*float64(q.duration))
+	}
+	q.handOver()
+}
+
+// handOver passes a slot on to the first waiting request or frees it. The
+// mutex must be held.
+func (q *requestQueue) handOver() {
+	front := q.waiting.Front()
+	if front == nil {
+		q.active--
+		return
+	}
+	q.waiting.Remove(front)
+	close(front.Value.(chan struct{}))
+}
+
+// retryAfter estimates in seconds how long it takes until a new request would
+// get a slot, based on the average duration of the recent runs.
+func (q *requestQueue) retryAfter() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if q.slots <= 0 || q.duration == 0 {
+		return 1
+	}
+	wait := float64(q.waiting.Len()+1) * q.duration.Seconds() / float64(q.slots)
+	return int(math.Max(1, math.Ceil(wait)))
+}
+
+// activeRuns returns the number of slots in use.
+func (q *requestQueue) activeRuns() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.active
+}
+
+// saturated reports whether a new request would be rejected, because all
+// slots are busy and the queue is full.
+func (q *requestQueue) saturated() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.active >= q.slots && q.waiting.Len() >= q.size
+}
+
+// depth returns the number of requests waiting for a slot.
+func (q *requestQueue) depth() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.waiting.Len()
+}
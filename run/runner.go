@@ -6,6 +6,10 @@ import "context"
 type Runner[RunnerConfig, Input, Option, Solution any] interface {
 	// Run runs the runner.
 	Run(context.Context) error
+	// RunWith runs the runner with the given IOProducer instead of the one
+	// set on the runner. The runner is not modified, so it is safe to call
+	// RunWith concurrently.
+	RunWith(context.Context, IOProducer[RunnerConfig]) error
 	// SetIOProducer sets the ioProducer of a runner.
 	SetIOProducer(IOProducer[RunnerConfig])
 	// SetInputDecoder sets the inputDecoder of a runner.
@@ -20,6 +24,9 @@ type Runner[RunnerConfig, Input, Option, Solution any] interface {
 	SetEncoder(Encoder[Solution, Option])
 	// GetEncoder returns the encoder of a runner.
 	GetEncoder() Encoder[Solution, Option]
+	// SetConfig sets the runnerConfig and the options of a runner. A runner
+	// which is configured this way does not parse flags.
+	SetConfig(RunnerConfig, Option)
 	// RunnerConfig returns the runnerConfig of a runner.
 	RunnerConfig() RunnerConfig
 }
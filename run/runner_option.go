@@ -16,6 +16,30 @@ func InputDecode[
 	}
 }
 
+// formatDecoder is implemented by runners which validate JSON inputs by
+// default, see InputDecodeFormat.
+type formatDecoder[Input any] interface {
+	setFormatDecoder(Decoder[Input])
+}
+
+// InputDecodeFormat sets the input decoder of a runner for a format other than
+// JSON. The JSON schema validation the runner does by default is turned off,
+// as the schema describes JSON, but a validator set with InputValidate is kept
+// regardless of the order of the options.
+func InputDecodeFormat[
+	RunnerConfig, Input, Option, Solution any,
+](i Decoder[Input]) func(
+	Runner[RunnerConfig, Input, Option, Solution],
+) {
+	return func(r Runner[RunnerConfig, Input, Option, Solution]) {
+		if f, ok := r.(formatDecoder[Input]); ok {
+			f.setFormatDecoder(i)
+			return
+		}
+		r.SetInputDecoder(i)
+	}
+}
+
 // InputValidate sets the input validator of a runner.
 func InputValidate[
 	RunnerConfig, Input, Option, Solution any,
@@ -59,3 +83,16 @@ func IOProduce[
 		r.SetIOProducer(i)
 	}
 }
+
+// Configure sets the runner configuration and the options of a runner, so that
+// no flags are parsed for it. This allows hosting several runners in one
+// program. Requests may still override the options.
+func Configure[
+	RunnerConfig, Input, Option, Solution any,
+](runnerConfig RunnerConfig, option Option) func(
+	Runner[RunnerConfig, Input, Option, Solution],
+) {
+	return func(r Runner[RunnerConfig, Input, Option, Solution]) {
+		r.SetConfig(runnerConfig, option)
+	}
+}
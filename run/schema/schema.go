@@ -35,6 +35,30 @@ func NewOutput[Solution any](options any, solutions ...Solution) Output {
 	}
 }
 
+// Aggregate returns a single Output holding all solutions of a run, e.g. if
+// all solutions are requested instead of the last one. Solutions which are an
+// Output are merged: their solutions are appended, and the version, options
+// and statistics are the ones of the last Output, as they describe the end of
+// the run. Other solutions are appended as they are.
+func Aggregate(solutions []any) Output {
+	aggregated := Output{Solutions: []any{}}
+	for _, solution := range solutions {
+		if pointer, ok := solution.(*Output); ok && pointer != nil {
+			solution = *pointer
+		}
+		output, ok := solution.(Output)
+		if !ok {
+			aggregated.Solutions = append(aggregated.Solutions, solution)
+			continue
+		}
+		aggregated.Solutions = append(aggregated.Solutions, output.Solutions...)
+		aggregated.Version = output.Version
+		aggregated.Options = output.Options
+		aggregated.Statistics = output.Statistics
+	}
+	return aggregated
+}
+
 // knownDependencies is a list of known dependencies that we want to put in the
 // version of the output.
 var knownDependencies = []struct {
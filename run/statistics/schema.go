@@ -21,7 +21,10 @@ type Statistics struct {
 type Run struct {
 	Duration   *float64 `json:"duration,omitempty"`
 	Iterations *int     `json:"iterations,omitempty"`
-	Custom     any      `json:"custom,omitempty"`
+	// Termination is set when the run was stopped before the algorithm
+	// returned on its own, e.g. because it hit the duration limit.
+	Termination string `json:"termination,omitempty"`
+	Custom      any    `json:"custom,omitempty"`
 }
 
 // Result is the structure of the result section of the statistics.
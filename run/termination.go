@@ -0,0 +1,93 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/nextmv-io/sdk/run/statistics"
+)
+
+// Termination describes why the runner stopped an algorithm before it returned
+// on its own. It is used as the cause of the cancelled algorithm context and it
+// is recorded in the statistics of the last solution, if that solution is a
+// schema.Output.
+type Termination string
+
+// Terminations set by the runner.
+const (
+	// TerminationDuration is used when the run exceeded its duration limit.
+	TerminationDuration Termination = "duration_limit"
+	// TerminationSignal is used when the process received a signal to stop.
+	TerminationSignal Termination = "signal"
+	// TerminationShutdown is used when the HTTPRunner stopped and the run did
+	// not finish within the shutdown grace period.
+	TerminationShutdown Termination = "shutdown"
+	// TerminationSolutions is used when the algorithm sent the maximum
+	// number of solutions.
+	TerminationSolutions Termination = "solution_limit"
+	// TerminationTarget is used when the value of a solution reached the
+	// target value.
+	TerminationTarget Termination = "target_value"
+	// TerminationImprovement is used when the value of the solutions did not
+	// improve within the improvement window.
+	TerminationImprovement Termination = "no_improvement"
+	// TerminationCanceled is used when the context of the run was cancelled
+	// without a more specific cause.
+	TerminationCanceled Termination = "canceled"
+)
+
+func (t Termination) Error() string {
+	return "run terminated: " + string(t)
+}
+
+// defaultGracePeriod is how long the runner waits for an algorithm to return
+// after its context was cancelled, unless the runner configuration is a
+// GraceLimiter.
+const defaultGracePeriod = time.Second
+
+// gracePeriod returns how long the runner waits for an algorithm to return
+// after its context was cancelled. Algorithms which do not return in that time
+// are abandoned and the last solution received before is encoded.
+func gracePeriod(runnerConfig any) time.Duration {
+	limiter, ok := runnerConfig.(GraceLimiter)
+	if !ok || limiter.GracePeriod() <= 0 {
+		return defaultGracePeriod
+	}
+	return limiter.GracePeriod()
+}
+
+// terminationOf returns the termination that explains why ctx is done.
+func terminationOf(ctx context.Context) Termination {
+	var termination Termination
+	if errors.As(context.Cause(ctx), &termination) {
+		return termination
+	}
+	return TerminationCanceled
+}
+
+// isTermination reports whether err is only the algorithm acknowledging that
+// its context was cancelled.
+func isTermination(err error) bool {
+	var termination Termination
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &termination)
+}
+
+// markTermination records the termination in the run statistics of the
+// solution. A schema.Output is copied, along with its statistics, so that an
+// output shared with the algorithm or the encoder is not modified. Other
+// solutions are returned unchanged.
+func markTermination[Solution any](
+	solution Solution, termination Termination,
+) Solution {
+	return updateStatistics(solution, func(stats *statistics.Statistics) {
+		run := statistics.Run{}
+		if stats.Run != nil {
+			run = *stats.Run
+		}
+		run.Termination = string(termination)
+		stats.Run = &run
+	})
+}
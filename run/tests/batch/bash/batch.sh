@@ -0,0 +1,19 @@
+echo "directory"
+./main.exe -runner.input.path instances -runner.output.dir out \
+  -runner.batch.workers 2 2>&1 | sed 's/^[0-9/]* [0-9:]* //'
+echo "exit code ${PIPESTATUS[0]}"
+(cd out && find . -type f | sort)
+jq -c '.solutions[0]' out/large/c.json
+cat out/b.yaml | grep -A1 solutions
+jq -c 'del(.duration) | .results[] |= del(.duration)' out/summary.json
+rm -r out
+
+echo "glob"
+./main.exe -runner.input.path 'instances/*/*.json' -runner.output.dir out
+jq -c '.results[] | [.input, .output, .status, .value]' out/summary.json
+rm -r out
+
+echo "no inputs"
+./main.exe -runner.input.path 'instances/*.toml' -runner.output.dir out 2>&1 \
+  | sed 's/^[0-9/]* [0-9:]* //'
+echo "exit code ${PIPESTATUS[0]}"
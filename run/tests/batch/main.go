@@ -0,0 +1,46 @@
+// package main holds the implementation of a runner which solves a directory
+// of instances in batch mode.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+	"github.com/nextmv-io/sdk/run/statistics"
+)
+
+func main() {
+	err := run.CLI(algorithm).Run(context.Background())
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+type input struct {
+	Items []int `json:"items"`
+}
+
+type option struct{}
+
+type output struct {
+	Sum int `json:"sum"`
+}
+
+// algorithm sums the items and reports the sum as the result value.
+func algorithm(
+	_ context.Context, input input, option option,
+) (schema.Output, error) {
+	sum := 0
+	for _, item := range input.Items {
+		sum += item
+	}
+	out := schema.NewOutput(option, output{Sum: sum})
+	out.Statistics = statistics.NewStatistics()
+	value := statistics.Float64(sum)
+	out.Statistics.Result = &statistics.Result{Value: &value}
+	return out, nil
+}
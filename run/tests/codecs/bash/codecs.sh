@@ -0,0 +1,25 @@
+echo "yaml to yaml"
+./main.exe -runner.input.path input.yaml -runner.output.path output.yaml
+cat output.yaml
+
+echo "toml to toml"
+./main.exe -runner.input.path input.toml -runner.output.path output.toml
+cat output.toml
+
+echo "yaml to json with options from a toml file"
+./main.exe -runner.input.path input.yaml -runner.config options.toml | jq -c '.total'
+
+echo "toml to gzipped yaml"
+./main.exe -runner.input.path input.toml -runner.output.path output.yaml.gz
+gunzip -c output.yaml.gz | head -3
+rm output.yaml output.toml output.yaml.gz
+
+echo "invalid yaml input"
+./main.exe -runner.input.path invalid.yaml 2>&1 | sed 's/^[0-9/]* [0-9:]* //'
+echo "exit code ${PIPESTATUS[0]}"
+
+echo "directory input"
+mkdir -p empty
+./main.exe -runner.input.path empty 2>&1 | sed 's/^[0-9/]* [0-9:]* //'
+echo "exit code ${PIPESTATUS[0]}"
+rmdir empty
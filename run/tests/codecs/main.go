@@ -0,0 +1,54 @@
+// package main holds the implementation of a runner which reads and writes the
+// format given by the extensions of its input and output paths.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
+func main() {
+	err := run.CLI(algorithm).Run(context.Background())
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+type input struct {
+	Name  string `json:"name"`
+	Stops []stop `json:"stops"`
+}
+
+type stop struct {
+	ID       string         `json:"id"`
+	Quantity int            `json:"quantity"`
+	Start    time.Time      `json:"start"`
+	Tags     []string       `json:"tags,omitempty"`
+	Location map[string]any `json:"location"`
+}
+
+type option struct {
+	Scale int `json:"scale" default:"1" usage:"Scale of the quantities"`
+}
+
+// output holds the input with scaled quantities.
+type output struct {
+	Name    string  `json:"name"`
+	Total   int     `json:"total"`
+	Stops   []stop  `json:"stops"`
+	Comment *string `json:"comment"`
+}
+
+func algorithm(_ context.Context, input input, option option) (output, error) {
+	out := output{Name: input.Name, Stops: input.Stops}
+	for i := range out.Stops {
+		out.Stops[i].Quantity *= option.Scale
+		out.Total += out.Stops[i].Quantity
+	}
+	return out, nil
+}
@@ -0,0 +1,24 @@
+summary='{costs: [.solutions[].cost],
+  termination: .statistics.run.termination}'
+
+echo "solution limit"
+./main.exe -runner.input.path input.json -runner.output.solutions all \
+  -runner.limits.solutions 2 | jq -c "$summary"
+
+echo "target value"
+./main.exe -runner.input.path input.json -runner.output.solutions all \
+  -runner.limits.target 40 | jq -c "$summary"
+
+echo "target value maximized"
+./main.exe -runner.input.path input.json -runner.output.solutions all \
+  -runner.limits.target 45 -runner.limits.objective maximize \
+  | jq -c "$summary"
+
+# the number of solutions on the plateau varies, so only the last is shown
+echo "no improvement"
+./main.exe -runner.input.path input.json -runner.limits.improvement 200ms \
+  | jq -c "$summary"
+
+echo "invalid target"
+./main.exe -runner.input.path input.json -runner.limits.target low 2>&1 \
+  | sed 's/^[0-9/]* [0-9:]* //'
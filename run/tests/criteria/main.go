@@ -0,0 +1,57 @@
+// package main holds the implementation of a runner which is stopped early
+// once its solutions meet the termination criteria.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.NewCLIRunner(algorithm).Run(context.Background())
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+type input struct {
+	Costs []float64 `json:"costs"`
+}
+
+type option struct {
+	Interval time.Duration `json:"interval" default:"20ms" usage:"Time between solutions."`
+}
+
+type output struct {
+	Cost float64 `json:"cost"`
+}
+
+// Value makes the runner check the cost of every solution.
+func (o output) Value() float64 {
+	return o.Cost
+}
+
+// algorithm sends a solution for every cost, after which it repeats the last
+// cost until the runner stops it.
+func algorithm(
+	ctx context.Context,
+	input input,
+	opts option,
+	solutions chan<- schema.Output,
+) error {
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.Interval):
+		}
+		cost := input.Costs[min(i, len(input.Costs)-1)]
+		solutions <- schema.NewOutput(opts, output{Cost: cost})
+	}
+}
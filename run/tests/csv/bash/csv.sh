@@ -0,0 +1,22 @@
+echo "directory"
+./main.exe -runner.input.path input | jq -c '.depots[], .stops[]'
+
+echo "zip archive of the directory"
+zip -q -r input.zip input
+./main.exe -runner.input.path input.zip | jq -c '.stops | length'
+rm input.zip
+
+echo "single file"
+./main.exe -runner.input.path input/stops.csv 2>&1 | sed 's/^[0-9/]* [0-9:]* //'
+echo "exit code ${PIPESTATUS[0]}"
+
+echo "invalid value"
+./main.exe -runner.input.path invalid 2>&1 | sed 's/^[0-9/]* [0-9:]* //'
+echo "exit code ${PIPESTATUS[0]}"
+
+echo "missing file"
+mkdir -p missing
+cp input/stops.csv missing/
+./main.exe -runner.input.path missing 2>&1 | sed 's/^[0-9/]* [0-9:]* //'
+echo "exit code ${PIPESTATUS[0]}"
+rm -r missing
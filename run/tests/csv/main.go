@@ -0,0 +1,70 @@
+// package main holds the implementation of a runner which reads its input from
+// CSV files.
+package main
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/cli"
+	"github.com/nextmv-io/sdk/run/decode"
+)
+
+func main() {
+	err := run.CLI(algorithm,
+		// the validator is kept, even though it is passed before the decoder
+		cli.Validate[input, option, input](validateFiles),
+		// every CSV file of the input directory fills a slice of the input
+		cli.Decode[input, option, input](decode.CSV()),
+	).Run(context.Background())
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+// input is filled from depots.csv and stops.csv.
+type input struct {
+	Depots []depot `json:"depots"`
+	Stops  []stop  `csv:"stops" json:"stops"`
+}
+
+type depot struct {
+	ID       string  `json:"id"`
+	Capacity int     `json:"capacity"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+}
+
+type stop struct {
+	ID       string        `csv:"stop_id" json:"id"`
+	Quantity int           `json:"quantity"`
+	Duration time.Duration `json:"duration"`
+	Start    time.Time     `json:"start"`
+	Fragile  bool          `json:"fragile"`
+	Weight   *float64      `json:"weight"`
+}
+
+type option struct{}
+
+// validateFiles checks that an input directory holds the depots.
+func validateFiles(_ context.Context, input any) error {
+	fsys, ok := input.(fs.FS)
+	if !ok {
+		return nil
+	}
+	if _, err := fs.Stat(fsys, "depots.csv"); err != nil {
+		return errors.New("depots.csv is missing")
+	}
+	return nil
+}
+
+// algorithm returns the decoded input.
+func algorithm(_ context.Context, input input, _ option) (input, error) {
+	return input, nil
+}
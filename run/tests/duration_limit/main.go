@@ -0,0 +1,49 @@
+// package main holds the implementation of a runner example with a duration
+// limit.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.NewCLIRunner(algorithm).Run(context.Background())
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+type input struct {
+	Message string `json:"message" usage:"Message to print."`
+}
+
+type option struct {
+	Interval time.Duration `json:"interval" default:"50ms" usage:"Time between solutions."`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+// algorithm keeps sending solutions and never returns. It ignores the context
+// on purpose, the runner stops it once the duration limit is reached.
+func algorithm(
+	_ context.Context,
+	input input,
+	opts option,
+	solutions chan<- schema.Output,
+) error {
+	for {
+		time.Sleep(opts.Interval)
+		solutions <- schema.NewOutput(
+			opts, output{Message: input.Message + " World!"},
+		)
+	}
+}
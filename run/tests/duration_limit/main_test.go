@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/nextmv-io/sdk/golden"
+)
+
+func TestMain(m *testing.M) {
+	golden.Setup()
+	code := m.Run()
+	golden.Teardown()
+	os.Exit(code)
+}
+
+// TestGolden executes a golden file test, where the .json input is fed and an
+// output is expected. The algorithm never returns, so the run ends at the
+// duration limit.
+func TestGolden(t *testing.T) {
+	golden.FileTests(
+		t,
+		"input.json",
+		golden.Config{
+			Args: []string{
+				"-runner.limits.duration=300ms",
+			},
+			TransientFields: []golden.TransientField{
+				{Key: ".version.sdk", Replacement: golden.StableVersion},
+			},
+		},
+	)
+}
+
+// TestGoldenAll checks that the runner stops accepting solutions once the
+// duration limit is reached, although the algorithm keeps sending them.
+func TestGoldenAll(t *testing.T) {
+	golden.FileTests(
+		t,
+		"input.json",
+		golden.Config{
+			Args: []string{
+				"-runner.limits.duration=300ms",
+				"-runner.output.solutions=all",
+			},
+			SkipGoldenComparison: true,
+			VerifyFunc: func(_, output []byte) error {
+				var out struct {
+					Solutions []any `json:"solutions"`
+				}
+				if err := json.Unmarshal(output, &out); err != nil {
+					return err
+				}
+				// a solution every 50ms within the limit of 300ms and the last one
+				// sent within the grace period
+				if len(out.Solutions) == 0 || len(out.Solutions) > 8 {
+					return fmt.Errorf("got %d solutions", len(out.Solutions))
+				}
+				return nil
+			},
+		},
+	)
+}
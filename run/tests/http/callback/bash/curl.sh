@@ -0,0 +1,29 @@
+if false; then
+go run main.go
+fi
+sleep 0.5
+go run main.go > /dev/null 2>&1 &
+sleep 3.5
+PID2=$(lsof -i -P | grep LISTEN | grep :9007 | tr -s ' ' | cut -d ' ' -f 2)
+
+# the callback succeeds on the third attempt
+curl -s -o /dev/null -X POST "http://localhost:9007" -H 'Content-Type: application/json' -d '{"message":"Hello"}'
+sleep 1
+cat callback.txt
+rm callback.txt
+
+# the callback of a failed run reports the error
+curl -s -o /dev/null -X POST "http://localhost:9007" -H 'Content-Type: application/json' -d '{"message":"Fail"}'
+sleep 1
+cat callback.txt
+echo
+rm callback.txt
+
+# the callback is rejected and handed to the dead letter hook
+curl -s -o /dev/null -X POST "http://localhost:9007" -H 'Content-Type: application/json' -d '{"message":"Reject"}'
+sleep 1
+cat dead_letter.txt
+rm dead_letter.txt
+
+kill $PID2 > /dev/null 2>&1
+exit 0
@@ -0,0 +1,114 @@
+// package main holds the implementation of an async runner whose callbacks
+// are retried, signed and sent to a dead letter hook if they fail.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+var secret = []byte("secret")
+
+func main() {
+	// start a callback server listening on port 9107
+	go func() {
+		err := http.ListenAndServe(":9107", http.HandlerFunc(callback))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	err := run.HTTP(algorithm,
+		// listen on port 9007
+		run.SetAddr[input, option, schema.Output](":9007"),
+		run.SetMaxParallel[input, option, schema.Output](2),
+		run.SetLogger[input, option, schema.Output](
+			log.New(io.Discard, "", 0),
+		),
+		run.SetHTTPRequestHandler[input, option, schema.Output](
+			run.AsyncHTTPRequestHandler(
+				run.CallbackURL("http://localhost:9107/callback"),
+				run.CallbackRetries(3),
+				run.CallbackBackoff(10*time.Millisecond, 50*time.Millisecond),
+				run.CallbackTimeout(time.Second),
+				run.CallbackSecret(secret),
+				run.DeadLetter(deadLetter),
+			),
+		),
+	).Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+var attempts sync.Map
+
+// callback fails the first two attempts and rejects outputs with the message
+// "Reject World!".
+func callback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	requestID := r.Header.Get("request_id")
+	count, _ := attempts.LoadOrStore(requestID, new(int))
+	*count.(*int)++
+	if *count.(*int) < 3 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if strings.Contains(string(body), "Reject World!") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	valid := run.VerifyCallback(secret, requestID, body, r.Header.Get("signature"))
+	write(
+		"callback.txt",
+		fmt.Sprintf(
+			"attempts: %d\nstatus: %s\nsignature valid: %t\n%s",
+			*count.(*int), r.Header.Get("status"), valid, body,
+		),
+	)
+}
+
+func deadLetter(_, _ string, body []byte, err error) {
+	write("dead_letter.txt", fmt.Sprintf("%v\n%s", err, body))
+}
+
+func write(name, content string) {
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type input struct {
+	Message string `json:"message"`
+}
+
+type option struct {
+	Duration time.Duration `json:"duration"`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+// algorithm fails for the message "Fail".
+func algorithm(_ context.Context, input input, opts option) (schema.Output, error) {
+	time.Sleep(opts.Duration)
+	if input.Message == "Fail" {
+		return schema.Output{}, errors.New("the algorithm failed")
+	}
+	return schema.NewOutput(opts, output{Message: input.Message + " World!"}), nil
+}
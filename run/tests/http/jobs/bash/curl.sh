@@ -0,0 +1,41 @@
+if false; then
+go run main.go
+fi
+sleep 0.5
+go run main.go -runner.http.queue.size 1 > /dev/null 2>&1 &
+sleep 3.5
+PID2=$(lsof -i -P | grep LISTEN | grep :9006 | tr -s ' ' | cut -d ' ' -f 2)
+URL="http://localhost:9006"
+
+# poll a run until it succeeded
+ID=$(curl -s -X POST "$URL?duration=1000000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}')
+sleep 0.2
+curl -s "$URL/runs/$ID" | jq -r .status
+curl -s -o /dev/null -w "result: %{http_code}\n" "$URL/runs/$ID/result"
+sleep 1.3
+curl -s "$URL/runs/$ID" | jq -r .status
+curl -s "$URL/runs/$ID/result" | jq .solutions
+curl -s -o /dev/null -w "delete: %{http_code}\n" -X DELETE "$URL/runs/$ID"
+curl -s -o /dev/null -w "status: %{http_code}\n" "$URL/runs/$ID"
+
+# cancel a run
+ID=$(curl -s -X POST "$URL?duration=60000000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}')
+sleep 0.5
+curl -s -o /dev/null -w "cancel: %{http_code}\n" -X DELETE "$URL/runs/$ID"
+sleep 0.5
+curl -s "$URL/runs/$ID" | jq -r .status
+curl -s "$URL/runs/$ID/result" | jq '{solutions, termination: .statistics.run.termination}'
+
+# cancel a run while it waits for a free slot
+curl -s -o /dev/null -X POST "$URL?duration=1000000000" -H 'Content-Type: application/json' -d '{"message":"Hello one"}'
+curl -s -o /dev/null -X POST "$URL?duration=1000000000" -H 'Content-Type: application/json' -d '{"message":"Hello two"}'
+ID=$(curl -s -X POST "$URL?duration=1000000000" -H 'Content-Type: application/json' -d '{"message":"Hello three"}')
+curl -s "$URL/runs/$ID" | jq -r .status
+curl -s -o /dev/null -w "queue full: %{http_code}\n" -X POST "$URL" -H 'Content-Type: application/json' -d '{"message":"Hello four"}'
+curl -s -o /dev/null -w "cancel: %{http_code}\n" -X DELETE "$URL/runs/$ID"
+sleep 0.1
+curl -s "$URL/runs/$ID" | jq -r .status
+curl -s -o /dev/null -w "result: %{http_code}\n" "$URL/runs/$ID/result"
+
+kill $PID2 > /dev/null 2>&1
+exit 0
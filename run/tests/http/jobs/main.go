@@ -0,0 +1,57 @@
+// package main holds the implementation of an async runner whose runs are
+// polled instead of being sent to a callback.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.HTTP(algorithm,
+		// listen on port 9006
+		run.SetAddr[input, option, schema.Output](":9006"),
+		run.SetMaxParallel[input, option, schema.Output](2),
+		run.SetLogger[input, option, schema.Output](
+			log.New(os.Stderr, "[demo] - ", log.LstdFlags),
+		),
+		run.SetHTTPRequestHandler[input, option, schema.Output](
+			run.AsyncHTTPRequestHandler(
+				// nothing listens on the callback URL, the output is kept in
+				// the job store nevertheless
+				run.CallbackURL("http://localhost:9106/callback"),
+			),
+		),
+	).Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type input struct {
+	Message string `json:"message" usage:"Message to print."`
+}
+
+type option struct {
+	Duration time.Duration `json:"duration" default:"1s" usage:"Sleep duration."`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+func algorithm(ctx context.Context, input input, opts option) (schema.Output, error) {
+	// sleep for the specified duration or until the run is cancelled
+	message := input.Message + " World!"
+	select {
+	case <-time.After(opts.Duration):
+	case <-ctx.Done():
+		message = input.Message + " cancelled!"
+	}
+	return schema.NewOutput(opts, output{Message: message}), nil
+}
@@ -0,0 +1,28 @@
+./main.exe -runner.http.maxparallel 1 > /dev/null 2>&1 &
+SERVER=$!
+sleep 1
+URL="http://localhost:9010"
+probe() {
+  curl -s -w "%{http_code} " "$URL/$1"
+  echo
+}
+
+probe healthz
+probe readyz
+curl -s -X POST "$URL" -H 'Content-Type: application/json' -d '{"message":"Hello"}' > /dev/null
+curl -s -X POST "$URL" -H 'Content-Type: application/json' -d '{"message"' > /dev/null
+
+# all slots are busy and there is no queue, so the runner is not ready
+curl -s -X POST "$URL?duration=1000000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}' > /dev/null &
+RUN=$!
+sleep 0.2
+probe readyz
+curl -s -X POST "$URL" -H 'Content-Type: application/json' -d '{"message":"Hello"}' > /dev/null
+
+# durations vary, so only the counts are shown
+curl -s "$URL/metrics" | grep -v "_bucket\|_sum"
+wait $RUN
+probe readyz
+
+kill $SERVER > /dev/null 2>&1
+exit 0
@@ -0,0 +1,43 @@
+// package main holds the implementation of a runner which serves probes and
+// metrics.
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.HTTP(algorithm,
+		// listen on port 9010
+		run.SetAddr[input, option, schema.Output](":9010"),
+		run.SetLogger[input, option, schema.Output](
+			log.New(io.Discard, "", 0),
+		),
+	).Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type input struct {
+	Message string `json:"message"`
+}
+
+type option struct {
+	Duration time.Duration `json:"duration"`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+func algorithm(_ context.Context, input input, opts option) (schema.Output, error) {
+	time.Sleep(opts.Duration)
+	return schema.NewOutput(opts, output{Message: input.Message + " World!"}), nil
+}
@@ -0,0 +1,39 @@
+./main.exe > /dev/null 2>&1 &
+SERVER=$!
+sleep 1
+URL="http://localhost:9012"
+JSON='{"message":"Hello"}'
+XML='<input><message>Hello</message></input>'
+post() {
+  curl -s -w "%{http_code} %{content_type}\n" -X POST "$URL" "$@"
+}
+
+echo "json"
+post -H 'Content-Type: application/json' -d "$JSON"
+echo "xml"
+post -H 'Content-Type: application/xml; charset=utf-8' -H 'Accept: application/xml' -d "$XML"
+echo "yaml"
+post -H 'Content-Type: application/yaml' -H 'Accept: application/yaml' -d 'message: Hello'
+echo "toml"
+post -H 'Content-Type: application/toml' -H 'Accept: application/toml' -d 'message = "Hello"'
+echo "preferred"
+post -H 'Content-Type: application/json' -H 'Accept: text/plain;q=0.5, application/*' -d "$JSON"
+echo "csv"
+post -H 'Content-Type: application/json' -H 'Accept: text/csv' -d "$JSON"
+echo "registered"
+post -H 'Content-Type: application/json' -H 'Accept: text/plain' -d "$JSON"
+echo "not acceptable"
+post -H 'Content-Type: application/json' -H 'Accept: image/png' -d "$JSON"
+echo "unsupported content type"
+post -H 'Content-Type: application/msgpack' -d 'Hello'
+echo "unsupported content encoding"
+post -H 'Content-Type: application/json' -H 'Content-Encoding: br' -d "$JSON"
+echo "gzip"
+echo "$JSON" | gzip -c | curl -s -D headers.txt -X POST "$URL" \
+  -H 'Content-Type: application/json' -H 'Content-Encoding: gzip' \
+  -H 'Accept-Encoding: gzip' --data-binary @- | gunzip -c
+grep -i "^content-encoding" headers.txt | tr -d '\r'
+rm headers.txt
+
+kill $SERVER > /dev/null 2>&1
+exit 0
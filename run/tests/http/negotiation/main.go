@@ -0,0 +1,49 @@
+// package main holds the implementation of a runner which decodes and encodes
+// the content types its clients ask for.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
+func main() {
+	err := run.HTTP(algorithm,
+		// listen on port 9012
+		run.SetAddr[input, option, output](":9012"),
+		run.SetLogger[input, option, output](
+			log.New(io.Discard, "", 0),
+		),
+		// JSON, YAML, TOML, XML, Gob and CSV are supported by default
+		run.RegisterEncoder[input, option, output]("text/plain", text{}),
+	).Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type input struct {
+	Message string `json:"message" xml:"message"`
+}
+
+type option struct{}
+
+type output struct {
+	Message string `json:"message" xml:"message"`
+}
+
+func algorithm(_ context.Context, input input, _ option) (output, error) {
+	return output{Message: input.Message + " World!"}, nil
+}
+
+// text encodes the output as plain text.
+type text struct{}
+
+func (text) Encode(w io.Writer, v any) error {
+	_, err := fmt.Fprintln(w, v.(output).Message)
+	return err
+}
@@ -0,0 +1,10 @@
+./main.exe > /dev/null 2>&1 &
+SERVER=$!
+sleep 1
+URL="http://localhost:9011"
+
+# the title and version depend on the build
+curl -s "$URL/openapi.json" | jq '.info = "<info>"'
+
+kill $SERVER > /dev/null 2>&1
+exit 0
@@ -0,0 +1,48 @@
+// package main holds the implementation of a runner which describes its API
+// with an OpenAPI document.
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.HTTP(algorithm,
+		// listen on port 9011
+		run.SetAddr[input, option, schema.Output](":9011"),
+		run.SetLogger[input, option, schema.Output](
+			log.New(io.Discard, "", 0),
+		),
+	).Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type input struct {
+	Message string `json:"message" doc:"Message to print."`
+	Repeat  int    `json:"repeat,omitempty" minimum:"1"`
+}
+
+type option struct {
+	Duration time.Duration `json:"duration" default:"1s" usage:"Sleep duration."`
+	Solve    struct {
+		Iterations int      `json:"iterations" default:"10" usage:"Number of iterations."`
+		Methods    []string `json:"methods" default:"greedy,local" usage:"Methods to apply."`
+	} `json:"solve"`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+func algorithm(_ context.Context, input input, opts option) (schema.Output, error) {
+	time.Sleep(opts.Duration)
+	return schema.NewOutput(opts, output{Message: input.Message + " World!"}), nil
+}
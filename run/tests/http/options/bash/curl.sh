@@ -0,0 +1,10 @@
+if false; then
+go run main.go
+fi
+sleep 0.5
+SOLVE_SEED=7 go run main.go -solve.iterations 20 > /dev/null 2>&1 &
+sleep 3.5
+PID2=$(lsof -i -P | grep LISTEN | grep :9005 | tr -s ' ' | cut -d ' ' -f 2)
+curl -s -X POST "http://localhost:9005?duration=5000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}' | jq
+kill $PID2 > /dev/null 2>&1
+exit 0
@@ -0,0 +1,56 @@
+// package main holds the implementation of a runner example that merges
+// options from several layers.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
+func main() {
+	err := run.HTTP(algorithm,
+		// listen on port 9005
+		run.SetAddr[input, option, output](":9005"),
+		// override the default logger
+		run.SetLogger[input, option, output](
+			log.New(os.Stdout, "[demo] - ", log.LstdFlags),
+		),
+	).Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type input struct {
+	Message string `json:"message" usage:"Message to print."`
+}
+
+type option struct {
+	Duration time.Duration `json:"duration" default:"1s" usage:"Sleep duration."`
+	Solve    struct {
+		Iterations int `json:"iterations" default:"10" usage:"Number of iterations."`
+		Seed       int `json:"seed" usage:"Random seed."`
+	} `json:"solve"`
+}
+
+type output struct {
+	Message string            `json:"message"`
+	Options option            `json:"options"`
+	Sources run.OptionSources `json:"sources"`
+}
+
+func algorithm(ctx context.Context, input input, opts option) (output, error) {
+	// sleep for the specified duration, 1s by default as defined via go tags
+	time.Sleep(opts.Duration)
+	// the runner reports which layer supplied every option
+	sources, _ := ctx.Value(run.Sources).(run.OptionSources)
+	return output{
+		Message: input.Message + " World!",
+		Options: opts,
+		Sources: sources,
+	}, nil
+}
@@ -0,0 +1,31 @@
+./main.exe -runner.http.maxparallel 1 -runner.http.queue.size 1 -runner.http.queue.timeout 1500ms > /dev/null 2>&1 &
+SERVER=$!
+sleep 1
+URL="http://localhost:9009"
+post() {
+  curl -s -D - -o /dev/null -X POST "$URL?duration=$1" -H 'Content-Type: application/json' -d '{"message":"Hello"}' | \
+    grep -i "^HTTP\|^retry-after" | cut -d ' ' -f 1,2 | tr -d '\r' | sed "s/^/$2: /"
+}
+
+# the second request waits for the first one, the third one finds the queue full
+post 1000000000 first > first.txt &
+FIRST=$!
+sleep 0.2
+post 500000000 second > second.txt &
+SECOND=$!
+sleep 0.2
+post 500000000 third
+wait $FIRST $SECOND
+cat first.txt second.txt
+
+# the fifth request does not get a slot within the queue timeout
+post 3000000000 fourth > fourth.txt &
+FOURTH=$!
+sleep 0.2
+post 500000000 fifth | sed "s/\(Retry-After:\) [0-9]*$/\1 <seconds>/"
+wait $FOURTH
+cat fourth.txt
+rm first.txt second.txt fourth.txt
+
+kill $SERVER > /dev/null 2>&1
+exit 0
@@ -0,0 +1,19 @@
+if false; then
+go run main.go
+fi
+sleep 0.5
+./main.exe -runner.http.shutdown.grace 1s > server.txt 2>&1 &
+sleep 1
+URL="http://localhost:9008"
+
+# the first run finishes within the grace period, the second one is cancelled
+curl -s -X POST "$URL?duration=500000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}' > first.json &
+curl -s -X POST "$URL?duration=60000000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}' > second.json &
+sleep 0.2
+PID2=$(lsof -i -P | grep LISTEN | grep :9008 | tr -s ' ' | cut -d ' ' -f 2)
+kill -TERM $PID2
+wait
+jq -c '.solutions' first.json
+jq -c '{solutions, termination: .statistics.run.termination}' second.json
+cat server.txt
+rm first.json second.json server.txt
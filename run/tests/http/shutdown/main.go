@@ -0,0 +1,59 @@
+// package main holds the implementation of a runner which shuts down
+// gracefully when it receives SIGTERM.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	err := run.HTTP(algorithm,
+		// listen on port 9008
+		run.SetAddr[input, option, schema.Output](":9008"),
+		run.SetMaxParallel[input, option, schema.Output](2),
+		run.SetLogger[input, option, schema.Output](
+			log.New(io.Discard, "", 0),
+		),
+	).Run(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("server stopped")
+	os.Exit(0)
+}
+
+type input struct {
+	Message string `json:"message"`
+}
+
+type option struct {
+	Duration time.Duration `json:"duration"`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+func algorithm(ctx context.Context, input input, opts option) (schema.Output, error) {
+	// sleep for the specified duration or until the run is cancelled
+	message := input.Message + " World!"
+	select {
+	case <-time.After(opts.Duration):
+	case <-ctx.Done():
+		message = input.Message + " cancelled!"
+	}
+	return schema.NewOutput(opts, output{Message: message}), nil
+}
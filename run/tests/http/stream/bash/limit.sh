@@ -0,0 +1,16 @@
+if false; then
+go run main.go
+fi
+sleep 0.5
+go run main.go -runner.http.stream ndjson -runner.limits.duration 700ms > /dev/null 2>&1 &
+sleep 3.5
+PID2=$(lsof -i -P | grep LISTEN | grep :9004 | tr -s ' ' | cut -d ' ' -f 2)
+summary='[.solutions[0].message, .statistics.run.termination]'
+
+# the first solution arrives before the run is terminated
+timeout 0.65 curl -s -N -X POST "http://localhost:9004?interval=500000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}' | jq -c "$summary"
+
+# every solution is streamed once, also when the run is terminated after it
+curl -s -N -X POST "http://localhost:9004?interval=500000000" -H 'Content-Type: application/json' -d '{"message":"Hello"}' | jq -c "$summary"
+kill $PID2 > /dev/null 2>&1
+exit 0
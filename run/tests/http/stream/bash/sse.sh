@@ -0,0 +1,10 @@
+if false; then
+go run main.go
+fi
+sleep 0.5
+go run main.go -runner.http.stream sse > /dev/null 2>&1 &
+sleep 3.5
+PID2=$(lsof -i -P | grep LISTEN | grep :9004 | tr -s ' ' | cut -d ' ' -f 2)
+curl -s -N -X POST "http://localhost:9004?solutions=2" -H 'Content-Type: application/json' -d '{"message":"Hello"}' | sed -n "s/^data: //p" | jq -c ".solutions"
+kill $PID2 > /dev/null 2>&1
+exit 0
@@ -0,0 +1,63 @@
+// package main holds the implementation of a runner example that streams
+// solutions.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.NewHTTPRunner(algorithm,
+		// listen on port 9004
+		run.SetAddr[input, option, schema.Output](":9004"),
+		// override the default logger
+		run.SetLogger[input, option, schema.Output](
+			log.New(os.Stdout, "[demo] - ", log.LstdFlags),
+		),
+	).Run(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+type input struct {
+	Message string `json:"message" usage:"Message to print."`
+}
+
+type option struct {
+	Solutions int           `json:"solutions" default:"3" usage:"Number of solutions."`
+	Interval  time.Duration `json:"interval" default:"100ms" usage:"Time between solutions."`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+// algorithm sends an improving solution after every interval until it is
+// cancelled.
+func algorithm(
+	ctx context.Context,
+	input input,
+	opts option,
+	solutions chan<- schema.Output,
+) error {
+	for i := 1; i <= opts.Solutions; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(opts.Interval):
+		}
+		solutions <- schema.NewOutput(
+			opts,
+			output{Message: fmt.Sprintf("%s World! (%d)", input.Message, i)},
+		)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+if false; then
+go run main.go
+fi
+sleep 0.5
+go run main.go > /dev/null 2>&1 &
+sleep 3.5
+PID2=$(lsof -i -P | grep LISTEN | grep :9000 | tr -s ' ' | cut -d ' ' -f 2)
+curl -s -o response.json -w "status: %{http_code}\n" -X POST "http://localhost:9000" -H 'Content-Type: application/json' -d '{"message":1,"unknown/key":true}'
+jq . response.json
+rm response.json
+kill $PID2 > /dev/null 2>&1
+exit 0
@@ -0,0 +1,11 @@
+# the fresh solution differs from the recorded output
+./main.exe -runner.input.path input.json -runner.replay.diff 2>&1 | \
+    sed "s/^[0-9\/]* [0-9:]* //"
+echo "exit code: ${PIPESTATUS[0]}"
+
+# the fresh solution matches the recorded output
+./main.exe -runner.input.path matching.json -runner.replay.diff \
+    -runner.output.path output.json
+echo "exit code: $?"
+jq . output.json
+rm output.json
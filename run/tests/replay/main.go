@@ -0,0 +1,38 @@
+// package main holds the implementation of a runner which replays recorded
+// outputs.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.CLI(algorithm).Run(context.Background())
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+type input struct {
+	Message string `json:"message"`
+}
+
+type option struct {
+	Punctuation string `json:"punctuation" default:"!"`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+func algorithm(_ context.Context, input input, opts option) (schema.Output, error) {
+	return schema.NewOutput(
+		opts, output{Message: input.Message + " World" + opts.Punctuation},
+	), nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/nextmv-io/sdk/golden"
+)
+
+func TestMain(m *testing.M) {
+	golden.Setup()
+	code := m.Run()
+	golden.Teardown()
+	os.Exit(code)
+}
+
+// TestGolden executes a golden file test, where the input carries a recorded
+// output which is returned instead of running the algorithm.
+func TestGolden(t *testing.T) {
+	golden.FileTests(t, "input.json", golden.Config{})
+}
+
+// TestGoldenBash executes a golden file test, where the bash file is run and
+// the output is compared against the expected one.
+func TestGoldenBash(t *testing.T) {
+	golden.BashTest(t, "./bash", golden.BashConfig{
+		DisplayStdout: true,
+		DisplayStderr: true,
+	})
+}
@@ -0,0 +1,21 @@
+./main.exe \
+    -runner.input.path input.json \
+    -runner.output.path output.json.gz 2> /dev/null &
+PID=$!
+sleep 0.5
+kill -TERM $PID
+wait $PID
+echo "exit code: $?"
+gunzip -c output.json.gz | jq
+rm output.json.gz
+
+# the exit code of the signal is kept if the run fails as well
+./main.exe -runner.input.path input.json -runner.output.path output.json \
+    -fail 2> error.log &
+PID=$!
+sleep 0.5
+kill -TERM $PID
+wait $PID
+echo "exit code: $?"
+sed 's/^[0-9/]* [0-9:]* //' error.log
+rm output.json error.log
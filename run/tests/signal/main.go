@@ -0,0 +1,58 @@
+// package main holds the implementation of a runner example that is stopped
+// by a signal.
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	err := run.NewCLIRunner(algorithm).Run(context.Background())
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+type input struct {
+	Message string `json:"message" usage:"Message to print."`
+}
+
+type option struct {
+	Interval time.Duration `json:"interval" default:"50ms" usage:"Time between solutions."`
+	Fail     bool          `json:"fail" usage:"Return an error once cancelled."`
+}
+
+type output struct {
+	Message string `json:"message"`
+}
+
+// algorithm sends solutions until its context is cancelled and then sends its
+// best solution one last time. It fails after that if configured to.
+func algorithm(
+	ctx context.Context,
+	input input,
+	opts option,
+	solutions chan<- schema.Output,
+) error {
+	best := schema.NewOutput(opts, output{Message: input.Message + " World!"})
+	for {
+		select {
+		case <-ctx.Done():
+			solutions <- best
+			if opts.Fail {
+				return errors.New("cleanup failed")
+			}
+			return nil
+		case <-time.After(opts.Interval):
+			solutions <- best
+		}
+	}
+}
@@ -0,0 +1,4 @@
+go run main.go \
+    -runner.config config.yaml \
+    -runner.output.path output.json \
+    -runner.print.config
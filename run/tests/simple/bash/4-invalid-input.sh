@@ -0,0 +1,2 @@
+echo '{"message": 1}' | ./main.exe 2>&1 | sed "s/^[0-9\/]* [0-9:]* //"
+echo "exit code: ${PIPESTATUS[1]}"
@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"log"
+	"os"
 	"time"
 
 	"github.com/nextmv-io/sdk/run"
@@ -13,7 +14,8 @@ import (
 func main() {
 	err := run.CLI(algorithm).Run(context.Background())
 	if err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
 	}
 }
 
@@ -0,0 +1,40 @@
+# the times of the solutions vary, so only the values are shown
+values='{solutions, value: .statistics.result.value,
+  series: [.statistics.series_data.value.data_points[].y]}'
+
+echo "last"
+./main.exe -runner.input.path input.json | jq -c "$values"
+
+echo "result duration of the final solution"
+./main.exe -runner.input.path input.json \
+  | jq -c '.statistics | .result.duration == .series_data.value.data_points[-1].x'
+
+echo "all"
+./main.exe -runner.input.path input.json -runner.output.solutions all \
+  | jq -c "$values"
+
+echo "all gzipped"
+./main.exe -runner.input.path input.json -runner.output.solutions all \
+  -runner.output.path output.json.gz
+gunzip -c output.json.gz | jq -c '.solutions'
+rm output.json.gz
+
+echo "all as yaml"
+./main.exe -runner.input.path input.json -runner.output.solutions all \
+  -runner.output.path output.yaml
+sed -n '/^solutions:/,/^statistics:/p' output.yaml
+rm output.yaml
+
+echo "no solutions"
+./main.exe -runner.input.path input.json -runner.output.solutions all \
+  -improvements 0
+
+echo "timings"
+./main.exe -runner.input.path input.json -runner.output.timings \
+  | jq -c '.statistics.run | [(.duration > 0), (.custom.timings | keys),
+    (.custom.timings.first_solution <= .custom.timings.last_solution)]'
+
+echo "final timings"
+TIMINGS_PATH=timings.json ./main.exe -runner.input.path input.json > /dev/null
+jq -c '[(.encode > 0), keys]' timings.json
+rm timings.json
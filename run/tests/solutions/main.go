@@ -0,0 +1,69 @@
+// package main holds the implementation of a runner which finds several
+// solutions, to show how all of them are encoded.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/schema"
+)
+
+func main() {
+	ctx := context.Background()
+	if path := os.Getenv("TIMINGS_PATH"); path != "" {
+		// the final timings include the time it took to encode the output
+		ctx = run.WithTimingsObserver(ctx, func(timings run.Timings) {
+			data, err := json.Marshal(timings)
+			if err == nil {
+				err = os.WriteFile(path, data, 0o600)
+			}
+			if err != nil {
+				log.Print(err)
+			}
+		})
+	}
+	err := run.NewCLIRunner(algorithm).Run(ctx)
+	if err != nil {
+		log.Print(err)
+		os.Exit(run.ExitCode(err))
+	}
+}
+
+type input struct {
+	Items []int `json:"items"`
+}
+
+type option struct {
+	Improvements int `json:"improvements" default:"3" usage:"Number of solutions."`
+}
+
+type output struct {
+	Sum int `json:"sum"`
+}
+
+// Value makes the runner record the sum of every solution.
+func (o output) Value() float64 {
+	return float64(o.Sum)
+}
+
+// algorithm sends a solution with a smaller sum for every improvement.
+func algorithm(
+	_ context.Context,
+	input input,
+	opts option,
+	solutions chan<- schema.Output,
+) error {
+	sum := 0
+	for _, item := range input.Items {
+		sum += item
+	}
+	for i := 0; i < opts.Improvements; i++ {
+		solutions <- schema.NewOutput(opts, output{Sum: sum})
+		sum /= 2
+	}
+	return nil
+}
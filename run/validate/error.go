@@ -0,0 +1,113 @@
+package validate
+
+import (
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Error is returned by a validator if the input does not satisfy its schema.
+// It lists every violation, so that clients can point users to the offending
+// values.
+type Error struct {
+	// Violations of the schema.
+	Violations []Violation `json:"violations"`
+}
+
+// Violation describes a value of the input which violates the schema.
+type Violation struct {
+	// Pointer is the JSON pointer (RFC 6901) to the offending value, e.g.
+	// "/stops/0/id". The pointer is empty for the root of the input. For
+	// missing and additional properties it points to the property itself.
+	Pointer string `json:"pointer"`
+	// Keyword is the schema keyword which is violated, e.g. "required", or
+	// SyntaxKeyword if the input is not valid JSON.
+	Keyword string `json:"keyword"`
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+// SyntaxKeyword is the keyword of the violation of an input which is not valid
+// JSON.
+const SyntaxKeyword = "syntax"
+
+func (e Error) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString("input is invalid")
+	for _, violation := range e.Violations {
+		pointer := violation.Pointer
+		if pointer == "" {
+			pointer = "(root)"
+		}
+		sb.WriteString("\n" + pointer + ": " + violation.Message)
+	}
+	return sb.String()
+}
+
+// keywords maps the error types of gojsonschema to the schema keywords.
+var keywords = map[string]string{
+	"invalid_type":                    "type",
+	"number_any_of":                   "anyOf",
+	"number_one_of":                   "oneOf",
+	"number_all_of":                   "allOf",
+	"number_not":                      "not",
+	"missing_dependency":              "dependencies",
+	"array_no_additional_items":       "additionalItems",
+	"array_min_items":                 "minItems",
+	"array_max_items":                 "maxItems",
+	"unique":                          "uniqueItems",
+	"array_min_properties":            "minProperties",
+	"array_max_properties":            "maxProperties",
+	"additional_property_not_allowed": "additionalProperties",
+	"invalid_property_pattern":        "patternProperties",
+	"invalid_property_name":           "propertyNames",
+	"string_gte":                      "minLength",
+	"string_lte":                      "maxLength",
+	"multiple_of":                     "multipleOf",
+	"number_gte":                      "minimum",
+	"number_gt":                       "exclusiveMinimum",
+	"number_lte":                      "maximum",
+	"number_lt":                       "exclusiveMaximum",
+	"condition_then":                  "then",
+	"condition_else":                  "else",
+}
+
+// newError converts the errors of a gojsonschema result.
+func newError(resultErrors []gojsonschema.ResultError) Error {
+	violations := make([]Violation, len(resultErrors))
+	for i, resultError := range resultErrors {
+		keyword, ok := keywords[resultError.Type()]
+		if !ok {
+			// e.g. required, enum or format are named like the keyword
+			keyword = resultError.Type()
+		}
+		violations[i] = Violation{
+			Pointer: pointer(resultError),
+			Keyword: keyword,
+			Message: resultError.Description(),
+		}
+	}
+	return Error{Violations: violations}
+}
+
+// pointer returns the JSON pointer to the value of the result error.
+func pointer(resultError gojsonschema.ResultError) string {
+	// use a delimiter which is very unlikely to be part of a property name
+	const delimiter = "\x00"
+	tokens := strings.Split(resultError.Context().String(delimiter), delimiter)
+	// the first token is the root
+	tokens = tokens[1:]
+	switch resultError.Type() {
+	case "required", "additional_property_not_allowed":
+		if property, ok := resultError.Details()["property"].(string); ok {
+			tokens = append(tokens, property)
+		}
+	}
+	sb := strings.Builder{}
+	for _, token := range tokens {
+		token = strings.ReplaceAll(token, "~", "~0")
+		token = strings.ReplaceAll(token, "/", "~1")
+		sb.WriteString("/" + token)
+	}
+	return sb.String()
+}
@@ -5,11 +5,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
-	"strings"
+	"sync"
 
 	humaSchema "github.com/danielgtaylor/huma/schema"
 	"github.com/xeipuuv/gojsonschema"
@@ -21,42 +24,89 @@ const RecordedOutputKey = "__recorded_output"
 
 // JSON creates a JSON validator. If nil is passed as schema, the validator will
 // try to read schema.json in the current directory. If that file does not
-// exist, no validation will be performed.
+// exist, the schema is generated from the Input type. The schema is compiled
+// on the first validation and reused afterwards. Errors in the schema are
+// returned by every validation, use one of the constructors that return an
+// error, such as JSONFromFile, to detect them upfront.
 func JSON[Input any](schema []byte) func(_ context.Context, input any) error {
-	return JSONValidator[Input]{
+	return (&JSONValidator[Input]{
 		schema: schema,
-	}.Validate
+	}).Validate
 }
 
-// JSONValidator validates the input against a JSON schema.
+// JSONFromFile creates a JSON validator for the schema in the given file.
+func JSONFromFile(path string) (func(_ context.Context, input any) error, error) {
+	schema, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	return compiledJSON(schema)
+}
+
+// JSONFromFS creates a JSON validator for the schema in the given file of
+// fsys, e.g. an embed.FS.
+func JSONFromFS(
+	fsys fs.FS, path string,
+) (func(_ context.Context, input any) error, error) {
+	schema, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	return compiledJSON(schema)
+}
+
+// JSONFromType creates a JSON validator for the schema generated from the
+// Input type.
+func JSONFromType[Input any]() (func(_ context.Context, input any) error, error) {
+	schema, err := generateSchema[Input]()
+	if err != nil {
+		return nil, err
+	}
+	return compiledJSON(schema)
+}
+
+// compiledJSON creates a JSON validator whose schema is compiled right away.
+func compiledJSON(schema []byte) (func(_ context.Context, input any) error, error) {
+	validator := &JSONValidator[any]{schema: schema}
+	if _, err := validator.compile(); err != nil {
+		return nil, err
+	}
+	return validator.Validate, nil
+}
+
+// JSONValidator validates the input against a JSON schema. The schema is
+// compiled once and reused for all inputs.
 type JSONValidator[Input any] struct {
-	schema []byte
+	schema   []byte
+	once     sync.Once
+	compiled *gojsonschema.Schema
+	err      error
 }
 
-// Validate validates the input against a JSON schema.
-func (j JSONValidator[Input]) Validate(_ context.Context, input any) (retErr error) {
-	// no schema is given
-	if len(j.schema) == 0 {
-		// generate schema for input struct
-		s, err := humaSchema.Generate(reflect.TypeOf(new(Input)))
-		if err != nil {
-			log.Fatal(err)
-		}
-		if s.Properties == nil {
-			s.Properties = map[string]*humaSchema.Schema{}
+// compile compiles the schema on the first call and returns the result of
+// that call afterwards.
+func (j *JSONValidator[Input]) compile() (*gojsonschema.Schema, error) {
+	j.once.Do(func() {
+		schema := j.schema
+		if len(schema) == 0 {
+			schema, j.err = defaultSchema[Input]()
+			if j.err != nil {
+				return
+			}
 		}
-		if _, ok := s.Properties[RecordedOutputKey]; !ok {
-			s.Properties[RecordedOutputKey] = &humaSchema.Schema{}
-		}
-		// serialize s to json
-		schema, err := json.Marshal(s)
-		if err != nil {
-			log.Fatal(err)
-		}
-		j.schema = schema
+		j.compiled, j.err = compileSchema(schema)
+	})
+	return j.compiled, j.err
+}
+
+// Validate validates the input against a JSON schema. Input which is not
+// valid JSON is reported as an Error with a syntax violation.
+func (j *JSONValidator[Input]) Validate(_ context.Context, input any) (retErr error) {
+	schema, err := j.compile()
+	if err != nil {
+		return err
 	}
 
-	schemaLoader := gojsonschema.NewBytesLoader(j.schema)
 	// cast input to io.Reader
 	reader, ok := input.(io.Reader)
 	if !ok {
@@ -64,24 +114,76 @@ func (j JSONValidator[Input]) Validate(_ context.Context, input any) (retErr err
 	}
 
 	var buf bytes.Buffer
-	_, err := buf.ReadFrom(reader)
+	_, err = buf.ReadFrom(reader)
 	if err != nil {
 		return err
 	}
 
+	if !json.Valid(buf.Bytes()) {
+		// decode the input to describe the syntax error
+		var document any
+		err := json.Unmarshal(buf.Bytes(), &document)
+		return Error{Violations: []Violation{{
+			Keyword: SyntaxKeyword,
+			Message: err.Error(),
+		}}}
+	}
 	loader := gojsonschema.NewBytesLoader(buf.Bytes())
 
-	result, err := gojsonschema.Validate(schemaLoader, loader)
+	result, err := schema.Validate(loader)
 	if err != nil {
 		return err
 	}
 
 	if !result.Valid() {
-		sb := strings.Builder{}
-		for _, desc := range result.Errors() {
-			sb.WriteString(desc.String() + "\n")
-		}
-		return fmt.Errorf(sb.String())
+		return newError(result.Errors())
 	}
 	return nil
 }
+
+// defaultSchema reads schema.json in the current directory or, if there is no
+// such file, generates the schema from the Input type.
+func defaultSchema[Input any]() ([]byte, error) {
+	schema, err := os.ReadFile("schema.json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return generateSchema[Input]()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	return schema, nil
+}
+
+// generateSchema generates the schema of the Input type.
+func generateSchema[Input any]() ([]byte, error) {
+	s, err := humaSchema.Generate(reflect.TypeOf(new(Input)))
+	if err != nil {
+		return nil, fmt.Errorf("generating schema: %w", err)
+	}
+	return json.Marshal(s)
+}
+
+// compileSchema compiles the schema. The RecordedOutputKey property is added
+// to object schemas, so that inputs with a recorded output are accepted even
+// if additional properties are not allowed.
+func compileSchema(schema []byte) (*gojsonschema.Schema, error) {
+	var document any
+	if err := json.Unmarshal(schema, &document); err != nil {
+		return nil, fmt.Errorf("decoding schema: %w", err)
+	}
+	if object, ok := document.(map[string]any); ok {
+		properties, ok := object["properties"].(map[string]any)
+		if !ok {
+			properties = map[string]any{}
+			object["properties"] = properties
+		}
+		if _, ok := properties[RecordedOutputKey]; !ok {
+			properties[RecordedOutputKey] = map[string]any{}
+		}
+	}
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(document))
+	if err != nil {
+		return nil, fmt.Errorf("compiling schema: %w", err)
+	}
+	return compiled, nil
+}
@@ -0,0 +1,150 @@
+package validate_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nextmv-io/sdk/run/validate"
+)
+
+const schema = `{
+  "type": "object",
+  "properties": {"message": {"type": "string"}},
+  "required": ["message"],
+  "additionalProperties": false
+}`
+
+type input struct {
+	Message string `json:"message"`
+}
+
+func TestJSONFromFS(t *testing.T) {
+	fsys := fstest.MapFS{"schema.json": {Data: []byte(schema)}}
+	validator, err := validate.JSONFromFS(fsys, "schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		violations []validate.Violation
+	}{
+		{
+			name:  "valid",
+			input: `{"message": "Hello"}`,
+		},
+		{
+			name:  "recorded output",
+			input: `{"message": "Hello", "__recorded_output": {}}`,
+		},
+		{
+			name:  "missing property",
+			input: `{}`,
+			violations: []validate.Violation{
+				{
+					Pointer: "/message",
+					Keyword: "required",
+					Message: "message is required",
+				},
+			},
+		},
+		{
+			name:  "invalid type",
+			input: `{"message": 1}`,
+			violations: []validate.Violation{
+				{
+					Pointer: "/message",
+					Keyword: "type",
+					Message: "Invalid type. Expected: string, given: integer",
+				},
+			},
+		},
+		{
+			name:  "syntax",
+			input: `{"message"`,
+			violations: []validate.Violation{
+				{
+					Keyword: validate.SyntaxKeyword,
+					Message: "unexpected end of JSON input",
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validator(context.Background(), strings.NewReader(test.input))
+			if test.violations == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			var validationErr validate.Error
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("got error %v, want a validate.Error", err)
+			}
+			if !reflect.DeepEqual(validationErr.Violations, test.violations) {
+				t.Errorf(
+					"got violations %v, want %v",
+					validationErr.Violations, test.violations,
+				)
+			}
+		})
+	}
+}
+
+func TestJSONFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(`{"type": 1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validate.JSONFromFile(path); err == nil {
+		t.Error("expected an error for an invalid schema")
+	}
+	if _, err := validate.JSONFromFile(path + ".missing"); err == nil {
+		t.Error("expected an error for a missing schema")
+	}
+}
+
+func TestJSONFromType(t *testing.T) {
+	validator, err := validate.JSONFromType[input]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = validator(context.Background(), strings.NewReader(`{"message": 1}`))
+	if !errors.As(err, &validate.Error{}) {
+		t.Errorf("got error %v, want a validate.Error", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	// the schema is read from schema.json in the current directory
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := os.WriteFile("schema.json", []byte(schema), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	validator := validate.JSON[any](nil)
+	err = validator(context.Background(), strings.NewReader(`{"other": 1}`))
+	if !errors.As(err, &validate.Error{}) {
+		t.Errorf("got error %v, want a validate.Error", err)
+	}
+}
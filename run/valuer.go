@@ -0,0 +1,120 @@
+package run
+
+import (
+	"context"
+	"time"
+
+	"github.com/nextmv-io/sdk/run/schema"
+	"github.com/nextmv-io/sdk/run/statistics"
+)
+
+// Valuer is the interface a solution can implement to report its value, e.g.
+// the objective of an optimization. The runner records the value of every
+// solution the algorithm sends, see ValuesOf.
+//
+// If the solution is a schema.Output, the value of the last of its solutions
+// which implements Valuer is used or, if there is none, the result value of
+// its statistics. The runner adds the values of all solutions to the series
+// data of the statistics of the final schema.Output solution it encodes, and
+// sets its result value and duration, unless the algorithm set them itself.
+type Valuer interface {
+	Value() float64
+}
+
+// solutionValue returns the value of a solution, see Valuer.
+func solutionValue(solution any) (float64, bool) {
+	if valuer, ok := solution.(Valuer); ok {
+		return valuer.Value(), true
+	}
+	var output schema.Output
+	switch s := solution.(type) {
+	case schema.Output:
+		output = s
+	case *schema.Output:
+		if s == nil {
+			return 0, false
+		}
+		output = *s
+	default:
+		return 0, false
+	}
+	for i := len(output.Solutions) - 1; i >= 0; i-- {
+		if valuer, ok := output.Solutions[i].(Valuer); ok {
+			return valuer.Value(), true
+		}
+	}
+	if output.Statistics != nil && output.Statistics.Result != nil &&
+		output.Statistics.Result.Value != nil {
+		return float64(*output.Statistics.Result.Value), true
+	}
+	return 0, false
+}
+
+// ValuesOf returns the values of the solutions of the run of ctx so far, with
+// the seconds since the start of the algorithm as X and the value as Y. It is
+// empty if the solutions do not have a value, see Valuer.
+func ValuesOf(ctx context.Context) []statistics.DataPoint {
+	t, ok := ctx.Value(timerKey).(*timer)
+	if !ok {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]statistics.DataPoint(nil), t.values...)
+}
+
+// valueSeries is the name of the series of the solution values.
+const valueSeries = "value"
+
+// reportValues adds the values recorded so far to the statistics of the final
+// solution, if it is a schema.Output with a value. As the final solution is
+// the last one recorded, the result duration is the time of the last value,
+// i.e. the time from the start of the algorithm until it sent the solution.
+func reportValues[Solution any](ctx context.Context, solution Solution) Solution {
+	value, ok := solutionValue(solution)
+	if !ok {
+		return solution
+	}
+	values := ValuesOf(ctx)
+	if len(values) == 0 {
+		return solution
+	}
+	return updateStatistics(solution, func(stats *statistics.Statistics) {
+		result := statistics.Result{}
+		if stats.Result != nil {
+			result = *stats.Result
+		}
+		if result.Value == nil {
+			v := statistics.Float64(value)
+			result.Value = &v
+		}
+		if result.Duration == nil {
+			duration := float64(values[len(values)-1].X)
+			result.Duration = &duration
+		}
+		stats.Result = &result
+
+		series := statistics.SeriesData{}
+		if stats.SeriesData != nil {
+			series = *stats.SeriesData
+		}
+		if len(series.Value.DataPoints) == 0 {
+			series.Value = statistics.Series{
+				Name:       valueSeries,
+				DataPoints: values,
+			}
+		}
+		stats.SeriesData = &series
+	})
+}
+
+// recordValue records the value of a solution the algorithm sent after the
+// given time since its start.
+func (t *timer) recordValue(solution any, elapsed time.Duration) {
+	if value, ok := solutionValue(solution); ok {
+		t.values = append(t.values, statistics.DataPoint{
+			X: statistics.Float64(elapsed.Seconds()),
+			Y: statistics.Float64(value),
+		})
+	}
+}